@@ -4,6 +4,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/rayomqio/benchmq/internal/bench"
 	"github.com/rayomqio/benchmq/pkg/logger"
@@ -13,7 +14,10 @@ import (
 var connCmd = &cobra.Command{
 	Use:   "conn",
 	Short: "Run a connection benchmark against the configured MQTT broker.",
-	Long:  `Opens N concurrent MQTT connections (from config or flags) to measure connection throughput, failures, and timing.`,
+	Long: `Opens N concurrent MQTT connections (from config or flags) to measure connection throughput, failures, and timing.
+
+With --hold, every connection stays open for the given duration while benchmq measures the round trip of each
+PINGREQ to its PINGRESP, reported overall and per --ping-window, along with keepalive-timeout disconnects.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		sigs := make(chan os.Signal, 1)
 		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
@@ -61,17 +65,79 @@ var connCmd = &cobra.Command{
 			return
 		}
 
+		connectConcurrency, err := cmd.Flags().GetInt("connect-concurrency")
+		if err != nil {
+			logger.Error("Failed to parse connect concurrency", logger.ErrorAttr(err))
+			return
+		}
+
+		connectRate, err := cmd.Flags().GetFloat64("connect-rate")
+		if err != nil {
+			logger.Error("Failed to parse connect rate", logger.ErrorAttr(err))
+			return
+		}
+
+		iterations, err := cmd.Flags().GetInt("iterations")
+		if err != nil {
+			logger.Error("Failed to parse iterations", logger.ErrorAttr(err))
+			return
+		}
+
+		cooldown, err := cmd.Flags().GetDuration("cooldown")
+		if err != nil {
+			logger.Error("Failed to parse cooldown", logger.ErrorAttr(err))
+			return
+		}
+
+		export, err := cmd.Flags().GetString("export")
+		if err != nil {
+			logger.Error("Failed to parse export path", logger.ErrorAttr(err))
+			return
+		}
+
+		sysTopics, err := cmd.Flags().GetBool("sys-topics")
+		if err != nil {
+			logger.Error("Failed to parse sys topics flag", logger.ErrorAttr(err))
+			return
+		}
+
+		hold, err := cmd.Flags().GetDuration("hold")
+		if err != nil {
+			logger.Error("Failed to parse hold", logger.ErrorAttr(err))
+			return
+		}
+
+		pingWindow, err := cmd.Flags().GetDuration("ping-window")
+		if err != nil {
+			logger.Error("Failed to parse ping window", logger.ErrorAttr(err))
+			return
+		}
+
+		broker, err := brokerOptions(cmd)
+		if err != nil {
+			logger.Error("Failed to parse broker flags", logger.ErrorAttr(err))
+			return
+		}
+
 		// Create benchmark
-		b, err := bench.NewBenchmark(
-			Cfg,
+		opts := []bench.Option{
 			bench.WithClients(clients),
 			bench.WithDelay(delay),
-			bench.WithCleanSession(clean),
-			bench.WithKeepAlive(keepalive),
-			bench.WithClientID(clientID),
-			bench.WithUsername(username),
-			bench.WithPassword(password),
-		)
+			ifChanged(cmd, "clean", bench.WithCleanSession(clean)),
+			ifChanged(cmd, "keepalive", bench.WithKeepAlive(keepalive)),
+			clientIDOption(cmd, clientID),
+			ifChanged(cmd, "username", bench.WithUsername(username)),
+			ifChanged(cmd, "password", bench.WithPassword(password)),
+			bench.WithConnectConcurrency(connectConcurrency),
+			bench.WithConnectRate(connectRate),
+			bench.WithIterations(iterations),
+			bench.WithCooldown(cooldown),
+			bench.WithSysTopics(sysTopics),
+			bench.WithHold(hold, pingWindow),
+		}
+		opts = append(opts, broker...)
+		opts = append(opts, configOptions(cmd, Cfg.Conn)...)
+		b, err := bench.NewBenchmark(Cfg, opts...)
 		if err != nil {
 			logger.Error("Failed to create benchmark", logger.ErrorAttr(err))
 			return
@@ -79,8 +145,9 @@ var connCmd = &cobra.Command{
 
 		// Run benchmark in a goroutine so we can wait for shutdown
 		done := make(chan struct{})
+		var report *bench.Iterations
 		go func() {
-			b.RunConnections()
+			report = b.Repeat(b.RunConnections)
 			close(done)
 		}()
 
@@ -91,6 +158,14 @@ var connCmd = &cobra.Command{
 		case <-done:
 			logger.Info("Connection benchmark completed", logger.State("completed"))
 		}
+
+		if export != "" {
+			if err := bench.Export(export, report); err != nil {
+				logger.Error("Failed to export report", logger.ErrorAttr(err))
+				return
+			}
+			logger.Info("Exported report", logger.String("path", export))
+		}
 	},
 }
 
@@ -100,9 +175,17 @@ func init() {
 	// Register flags
 	connCmd.Flags().StringP("clientID", "i", "benchmq-client", "Client ID for MQTT connections")
 	connCmd.Flags().IntP("clients", "c", 100, "Number of concurrent clients to connect")
-	connCmd.Flags().IntP("delay", "d", 1000, "Delay between each client connection in milliseconds")
+	connCmd.Flags().IntP("delay", "d", 1000, "Delay between each client connection in milliseconds (ignored when connect pacing is set)")
 	connCmd.Flags().BoolP("clean", "x", true, "Clean previous session when connecting")
 	connCmd.Flags().Uint16P("keepalive", "k", 60, "Keepalive interval in seconds")
 	connCmd.Flags().StringP("username", "u", "", "Username for MQTT connections")
 	connCmd.Flags().StringP("password", "p", "", "Password for MQTT connections")
+	connCmd.Flags().Int("connect-concurrency", 0, "Maximum in-flight CONNECT attempts (0 = unbounded)")
+	connCmd.Flags().Float64("connect-rate", 0, "Maximum new connections per second (0 = unlimited)")
+	connCmd.Flags().Int("iterations", 1, "Number of times to repeat the benchmark")
+	connCmd.Flags().Duration("cooldown", 0, "Pause between iterations (e.g. 10s)")
+	connCmd.Flags().String("export", "", "Write the JSON report to this file")
+	connCmd.Flags().Bool("sys-topics", false, "Record the broker's $SYS statistics during each run")
+	connCmd.Flags().Duration("hold", 0, "Keep every connection open this long and measure keepalive ping round trips (e.g. 10m)")
+	connCmd.Flags().Duration("ping-window", 10*time.Second, "Window of the ping round-trip distribution over time")
 }
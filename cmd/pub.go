@@ -4,6 +4,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/rayomqio/benchmq/internal/bench"
 	"github.com/rayomqio/benchmq/pkg/logger"
@@ -19,14 +20,37 @@ var pubCmd = &cobra.Command{
 Parameters:
 	- clientID: Base client ID prefix (each client appends "-<n>")
     - clients: Number of concurrent clients
-    - delay: Delay between messages in milliseconds
+    - delay: Delay (mean interval) between messages in milliseconds
     - count: Number of messages to publish per client
     - qos: Quality of service level (0, 1, 2)
-    - message: The message payload
-    - topic: Topic to publish to
+    - message: The message payload, rendered per publish when it contains {{ }} template actions
+    - message-file: Read the message or template from a file
+    - topic: Topic to publish to, rendered per publish when it contains {{ }} template actions
     - retain: Whether to retain the last message
     - clean: Whether to use a clean session
-    - keepalive: Keepalive interval in seconds`,
+    - keepalive: Keepalive interval in seconds
+    - connect-concurrency: Maximum in-flight CONNECT attempts (0 = unbounded)
+    - connect-rate: Maximum new connections per second (0 = unlimited)
+    - iterations: Number of times to repeat the benchmark
+    - cooldown: Pause between iterations
+    - export: Write the JSON report with per-iteration results to a file
+    - sys-topics: Subscribe a monitor client to $SYS/# and include the broker statistics in the report
+    - arrival: Inter-arrival distribution (constant, poisson, uniform, burst) with delay as mean
+    - jitter: Uniform jitter around the delay in milliseconds
+    - burst-size: Messages sent back-to-back per burst
+    - seed: Random seed for reproducible arrivals
+    - max-inflight: Unacknowledged publishes per client, pipelined through the ack callbacks
+    - publish-timeout: Wait for a publish acknowledgement before counting it failed
+    - warmup: Warm-up period whose traffic is reported separately
+    - warmup-count: Warm-up messages per client reported separately
+    - payload-size: Generated payload size, range or weighted list (overrides message)
+    - payload-content: Generated payload content (random, zeros, text, printable)
+    - payload-file: Replay newline-delimited or length-prefixed payloads from a file
+    - payload-format: Payload file framing (lines, length-prefixed)
+    - payload-dir: Replay every file in a directory as one payload
+    - payload-order: Corpus replay order per client (sequential, random)
+    - tag: Prefix payloads with publisher ID and sequence number so subscribers detect loss, duplicates and reordering
+    - checksum: Prefix payloads with a crc32 or xxhash integrity header that subscribers verify`,
 	Run: func(cmd *cobra.Command, args []string) {
 		sigs := make(chan os.Signal, 1)
 		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
@@ -105,21 +129,188 @@ Parameters:
 			return
 		}
 
-		b, err := bench.NewBenchmark(
-			Cfg,
-			bench.WithClientID(clientID),
+		connectConcurrency, err := cmd.Flags().GetInt("connect-concurrency")
+		if err != nil {
+			logger.Error("Failed to parse connect concurrency", logger.ErrorAttr(err))
+			return
+		}
+
+		connectRate, err := cmd.Flags().GetFloat64("connect-rate")
+		if err != nil {
+			logger.Error("Failed to parse connect rate", logger.ErrorAttr(err))
+			return
+		}
+
+		arrival, err := cmd.Flags().GetString("arrival")
+		if err != nil {
+			logger.Error("Failed to parse arrival", logger.ErrorAttr(err))
+			return
+		}
+
+		jitter, err := cmd.Flags().GetInt("jitter")
+		if err != nil {
+			logger.Error("Failed to parse jitter", logger.ErrorAttr(err))
+			return
+		}
+
+		burstSize, err := cmd.Flags().GetInt("burst-size")
+		if err != nil {
+			logger.Error("Failed to parse burst size", logger.ErrorAttr(err))
+			return
+		}
+
+		maxInflight, err := cmd.Flags().GetInt("max-inflight")
+		if err != nil {
+			logger.Error("Failed to parse max in-flight", logger.ErrorAttr(err))
+			return
+		}
+
+		publishTimeout, err := cmd.Flags().GetDuration("publish-timeout")
+		if err != nil {
+			logger.Error("Failed to parse publish timeout", logger.ErrorAttr(err))
+			return
+		}
+
+		seed, err := cmd.Flags().GetInt64("seed")
+		if err != nil {
+			logger.Error("Failed to parse seed", logger.ErrorAttr(err))
+			return
+		}
+
+		warmup, err := cmd.Flags().GetDuration("warmup")
+		if err != nil {
+			logger.Error("Failed to parse warm-up", logger.ErrorAttr(err))
+			return
+		}
+
+		warmupCount, err := cmd.Flags().GetInt("warmup-count")
+		if err != nil {
+			logger.Error("Failed to parse warm-up count", logger.ErrorAttr(err))
+			return
+		}
+
+		iterations, err := cmd.Flags().GetInt("iterations")
+		if err != nil {
+			logger.Error("Failed to parse iterations", logger.ErrorAttr(err))
+			return
+		}
+
+		cooldown, err := cmd.Flags().GetDuration("cooldown")
+		if err != nil {
+			logger.Error("Failed to parse cooldown", logger.ErrorAttr(err))
+			return
+		}
+
+		export, err := cmd.Flags().GetString("export")
+		if err != nil {
+			logger.Error("Failed to parse export path", logger.ErrorAttr(err))
+			return
+		}
+
+		sysTopics, err := cmd.Flags().GetBool("sys-topics")
+		if err != nil {
+			logger.Error("Failed to parse sys topics flag", logger.ErrorAttr(err))
+			return
+		}
+
+		payloadSize, err := cmd.Flags().GetString("payload-size")
+		if err != nil {
+			logger.Error("Failed to parse payload size", logger.ErrorAttr(err))
+			return
+		}
+
+		payloadContent, err := cmd.Flags().GetString("payload-content")
+		if err != nil {
+			logger.Error("Failed to parse payload content", logger.ErrorAttr(err))
+			return
+		}
+
+		messageFile, err := cmd.Flags().GetString("message-file")
+		if err != nil {
+			logger.Error("Failed to parse message file", logger.ErrorAttr(err))
+			return
+		}
+
+		payloadFile, err := cmd.Flags().GetString("payload-file")
+		if err != nil {
+			logger.Error("Failed to parse payload file", logger.ErrorAttr(err))
+			return
+		}
+
+		payloadFormat, err := cmd.Flags().GetString("payload-format")
+		if err != nil {
+			logger.Error("Failed to parse payload format", logger.ErrorAttr(err))
+			return
+		}
+
+		payloadDir, err := cmd.Flags().GetString("payload-dir")
+		if err != nil {
+			logger.Error("Failed to parse payload dir", logger.ErrorAttr(err))
+			return
+		}
+
+		payloadOrder, err := cmd.Flags().GetString("payload-order")
+		if err != nil {
+			logger.Error("Failed to parse payload order", logger.ErrorAttr(err))
+			return
+		}
+
+		tag, err := cmd.Flags().GetBool("tag")
+		if err != nil {
+			logger.Error("Failed to parse tag flag", logger.ErrorAttr(err))
+			return
+		}
+
+		checksum, err := cmd.Flags().GetString("checksum")
+		if err != nil {
+			logger.Error("Failed to parse checksum", logger.ErrorAttr(err))
+			return
+		}
+
+		broker, err := brokerOptions(cmd)
+		if err != nil {
+			logger.Error("Failed to parse broker flags", logger.ErrorAttr(err))
+			return
+		}
+
+		opts := []bench.Option{
+			clientIDOption(cmd, clientID),
 			bench.WithClients(clients),
 			bench.WithTopic(topic),
 			bench.WithQoS(qos),
 			bench.WithMessageCount(count),
 			bench.WithDelay(delay),
 			bench.WithRetained(retain),
-			bench.WithCleanSession(cleanSession),
-			bench.WithKeepAlive(keepalive),
+			ifChanged(cmd, "clean", bench.WithCleanSession(cleanSession)),
+			ifChanged(cmd, "keepalive", bench.WithKeepAlive(keepalive)),
 			bench.WithMessage(message),
-			bench.WithUsername(username),
-			bench.WithPassword(password),
-		)
+			bench.WithMessageFile(messageFile),
+			ifChanged(cmd, "username", bench.WithUsername(username)),
+			ifChanged(cmd, "password", bench.WithPassword(password)),
+			bench.WithConnectConcurrency(connectConcurrency),
+			bench.WithConnectRate(connectRate),
+			bench.WithIterations(iterations),
+			bench.WithCooldown(cooldown),
+			bench.WithSysTopics(sysTopics),
+			bench.WithArrival(bench.Arrival(arrival)),
+			bench.WithJitter(jitter),
+			bench.WithBurstSize(burstSize),
+			bench.WithMaxInflight(maxInflight),
+			bench.WithPublishTimeout(publishTimeout),
+			bench.WithSeed(seed),
+			bench.WithWarmup(warmup),
+			bench.WithWarmupCount(warmupCount),
+			bench.WithPayloadSize(payloadSize),
+			bench.WithPayloadContent(bench.PayloadContent(payloadContent)),
+			bench.WithPayloadFile(payloadFile, bench.CorpusFormat(payloadFormat)),
+			bench.WithPayloadDir(payloadDir),
+			bench.WithPayloadOrder(bench.CorpusOrder(payloadOrder)),
+			bench.WithSequenceTag(tag),
+			bench.WithChecksum(bench.Checksum(checksum)),
+		}
+		opts = append(opts, broker...)
+		opts = append(opts, configOptions(cmd, Cfg.Pub)...)
+		b, err := bench.NewBenchmark(Cfg, opts...)
 		if err != nil {
 			logger.Error("Failed to create benchmark", logger.State("failed"), logger.ErrorAttr(err))
 			return
@@ -131,7 +322,15 @@ Parameters:
 			os.Exit(0)
 		}()
 
-		b.PublishMessages()
+		report := b.Repeat(b.PublishMessages)
+
+		if export != "" {
+			if err := bench.Export(export, report); err != nil {
+				logger.Error("Failed to export report", logger.ErrorAttr(err))
+				return
+			}
+			logger.Info("Exported report", logger.String("path", export))
+		}
 	},
 }
 
@@ -145,10 +344,33 @@ func init() {
 	pubCmd.Flags().IntP("count", "n", 1000, "Number of messages to publish per client")
 	pubCmd.Flags().BoolP("retain", "r", false, "Retain the last message")
 	pubCmd.Flags().Uint16P("qos", "q", 0, "Quality of service level (0, 1, 2)")
-	pubCmd.Flags().StringP("message", "m", "Hello, World!", "Message to publish")
-	pubCmd.Flags().StringP("topic", "t", "benchmq", "Topic to publish messages to")
+	pubCmd.Flags().StringP("message", "m", "Hello, World!", "Message to publish, may be a text/template")
+	pubCmd.Flags().String("message-file", "", "Read the message (or message template) from a file")
+	pubCmd.Flags().StringP("topic", "t", "benchmq", "Topic to publish messages to, may be a text/template")
 	pubCmd.Flags().BoolP("clean", "x", true, "Clean previous session when connecting")
 	pubCmd.Flags().Uint16P("keepalive", "k", 60, "Keepalive interval in seconds")
 	pubCmd.Flags().StringP("username", "u", "", "Username for MQTT connections")
 	pubCmd.Flags().StringP("password", "p", "", "Password for MQTT connections")
+	pubCmd.Flags().Int("connect-concurrency", 0, "Maximum in-flight CONNECT attempts (0 = unbounded)")
+	pubCmd.Flags().Float64("connect-rate", 0, "Maximum new connections per second (0 = unlimited)")
+	pubCmd.Flags().Int("iterations", 1, "Number of times to repeat the benchmark")
+	pubCmd.Flags().Duration("cooldown", 0, "Pause between iterations (e.g. 10s)")
+	pubCmd.Flags().String("export", "", "Write the JSON report to this file")
+	pubCmd.Flags().Bool("sys-topics", false, "Record the broker's $SYS statistics during each run")
+	pubCmd.Flags().String("arrival", "constant", "Inter-arrival distribution (constant, poisson, uniform, burst)")
+	pubCmd.Flags().Int("jitter", 0, "Uniform jitter around the delay in milliseconds, at most the delay (0 = delay)")
+	pubCmd.Flags().Int("burst-size", 1, "Messages per burst for burst arrival")
+	pubCmd.Flags().Int("max-inflight", 1, "Unacknowledged publishes per client (1 = wait for every ack)")
+	pubCmd.Flags().Duration("publish-timeout", 30*time.Second, "Wait for a publish acknowledgement before counting it failed")
+	pubCmd.Flags().Int64("seed", 0, "Random seed for arrival distributions (0 = time based)")
+	pubCmd.Flags().Duration("warmup", 0, "Warm-up period excluded from the summary (e.g. 30s)")
+	pubCmd.Flags().Int("warmup-count", 0, "Warm-up messages per client excluded from the summary")
+	pubCmd.Flags().String("payload-size", "", "Generated payload size: 1024, 64KB, 512-4096 or 1KB:3,64KB:1 (overrides message)")
+	pubCmd.Flags().String("payload-content", "random", "Generated payload content (random, zeros, text, printable)")
+	pubCmd.Flags().String("payload-file", "", "Replay payloads from a file (overrides message)")
+	pubCmd.Flags().String("payload-format", "lines", "Payload file framing (lines, length-prefixed)")
+	pubCmd.Flags().String("payload-dir", "", "Replay every file in a directory as one payload (overrides message)")
+	pubCmd.Flags().String("payload-order", "sequential", "Corpus replay order per client (sequential, random)")
+	pubCmd.Flags().Bool("tag", false, "Prefix payloads with publisher ID and sequence number for loss, duplicate and reorder detection")
+	pubCmd.Flags().String("checksum", "", "Prefix payloads with an integrity header: crc32 or xxhash")
 }
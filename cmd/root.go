@@ -4,8 +4,11 @@ import (
 	"os"
 	"strings"
 
+	"github.com/rayomqio/benchmq/internal/bench"
 	"github.com/rayomqio/benchmq/pkg/config"
+	"github.com/rayomqio/benchmq/pkg/er"
 	"github.com/rayomqio/benchmq/pkg/logger"
+	"github.com/rayomqio/benchmq/pkg/redact"
 	"github.com/spf13/cobra"
 )
 
@@ -14,7 +17,26 @@ var Cfg *config.Config
 var rootCmd = &cobra.Command{
 	Use:   "benchmq",
 	Short: "BenchMQ is a simple, fast, and lightweight CLI to benchmark your MQTT broker with ease.",
-	Long:  `BenchMQ is a simple, fast, and open-source CLI tool for benchmarking MQTT brokers. Measure throughput, latency, and stability of your MQTT setup with ease.`,
+	Long: `BenchMQ is a simple, fast, and open-source CLI tool for benchmarking MQTT brokers. Measure throughput, latency, and stability of your MQTT setup with ease.
+
+Configuration precedence is flags > BENCHMQ_* environment variables > config file > defaults. Without --config,
+config.yml is looked up in the working directory, $XDG_CONFIG_HOME/benchmq and /etc/benchmq. --broker selects one
+of the named profiles under brokers in the config, which replaces its server section and credentials.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		path, err := cmd.Flags().GetString("config")
+		if err != nil {
+			logger.InitGlobalLogger(logger.DevelopmentConfig())
+			logger.Error("Failed to parse config path", logger.ErrorAttr(err))
+			os.Exit(1)
+		}
+		broker, err := cmd.Flags().GetString("broker")
+		if err != nil {
+			logger.InitGlobalLogger(logger.DevelopmentConfig())
+			logger.Error("Failed to parse broker profile", logger.ErrorAttr(err))
+			os.Exit(1)
+		}
+		initConfig(path, broker)
+	},
 }
 
 func Execute() {
@@ -25,7 +47,19 @@ func Execute() {
 }
 
 func init() {
-	cfg, err := config.InitializeCfg()
+	rootCmd.PersistentFlags().String("config", "", "Config file path (default: config.yml in ., $XDG_CONFIG_HOME/benchmq or /etc/benchmq)")
+	rootCmd.PersistentFlags().String("host", "", "MQTT broker host (default: server.host from the config, localhost)")
+	rootCmd.PersistentFlags().Uint16("port", 0, "MQTT broker port (default: server.port from the config, 1883)")
+	rootCmd.PersistentFlags().String("broker", "", "Named broker profile from the brokers section of the config")
+	rootCmd.PersistentFlags().String("password-env", "", "Read the MQTT password from this environment variable")
+	rootCmd.PersistentFlags().String("password-file", "", "Read the MQTT password from the first line of this file")
+	rootCmd.PersistentFlags().Bool("password-prompt", false, "Prompt for the MQTT password without echoing it")
+}
+
+// initConfig loads the config with the given broker profile and sets up the
+// global logger from it
+func initConfig(path, broker string) {
+	cfg, err := config.Load(path, broker)
 	if err != nil {
 		logger.InitGlobalLogger(logger.DevelopmentConfig())
 		logger.Error("Failed to initialize config", logger.ErrorAttr(err))
@@ -57,3 +91,125 @@ func init() {
 		logger.Warn("Invalid server environment config value, assigning default development.", logger.String("environment", Cfg.Environment))
 	}
 }
+
+// ifChanged returns opt only when flag was set on the command line, so config
+// file and environment values apply otherwise
+func ifChanged(cmd *cobra.Command, flag string, opt bench.Option) bench.Option {
+	if !cmd.Flags().Changed(flag) {
+		return nil
+	}
+	return opt
+}
+
+// clientIDOption returns the client ID given with --clientID. Without the
+// flag, a client ID set in the config file or environment applies, and the
+// command's own default otherwise.
+func clientIDOption(cmd *cobra.Command, clientID string) bench.Option {
+	if !cmd.Flags().Changed("clientID") && Cfg.Client.ClientID != config.DefaultClientID {
+		return nil
+	}
+	return bench.WithClientID(clientID)
+}
+
+// brokerOptions returns the broker given with --host and --port and the
+// password from a credential source flag, which take precedence over the config
+func brokerOptions(cmd *cobra.Command) ([]bench.Option, error) {
+	host, err := cmd.Flags().GetString("host")
+	if err != nil {
+		return nil, err
+	}
+	port, err := cmd.Flags().GetUint16("port")
+	if err != nil {
+		return nil, err
+	}
+	password, err := passwordOption(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return []bench.Option{
+		ifChanged(cmd, "host", bench.WithHost(host)),
+		ifChanged(cmd, "port", bench.WithPort(port)),
+		password,
+	}, nil
+}
+
+// passwordOption returns the password read from --password-env,
+// --password-file or --password-prompt, registering any password given on
+// the command line for redaction. At most one password flag may be set.
+func passwordOption(cmd *cobra.Command) (bench.Option, error) {
+	set := 0
+	for _, flag := range []string{"password", "password-env", "password-file", "password-prompt"} {
+		if cmd.Flags().Lookup(flag) != nil && cmd.Flags().Changed(flag) {
+			set++
+		}
+	}
+	if set > 1 {
+		return nil, er.ErrConflictingPassword
+	}
+
+	var password string
+	var err error
+	switch {
+	case cmd.Flags().Changed("password-env"):
+		var name string
+		if name, err = cmd.Flags().GetString("password-env"); err == nil {
+			password, err = config.PasswordFromEnv(name)
+		}
+	case cmd.Flags().Changed("password-file"):
+		var path string
+		if path, err = cmd.Flags().GetString("password-file"); err == nil {
+			password, err = config.PasswordFromFile(path)
+		}
+	case cmd.Flags().Changed("password-prompt"):
+		var prompt bool
+		if prompt, err = cmd.Flags().GetBool("password-prompt"); err != nil || !prompt {
+			return nil, err
+		}
+		password, err = config.PromptPassword("MQTT password: ")
+	case cmd.Flags().Lookup("password") != nil:
+		// Given with --password, which the command applies itself
+		password, err = cmd.Flags().GetString("password")
+		redact.Register(password)
+		return nil, err
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	redact.Register(password)
+	return bench.WithPassword(password), nil
+}
+
+// configOptions returns the options set in a benchmark section of the config
+// for the flags of cmd that weren't given on the command line
+func configOptions(cmd *cobra.Command, b config.Benchmark) []bench.Option {
+	var opts []bench.Option
+	set := func(flag string, opt bench.Option) {
+		if cmd.Flags().Lookup(flag) != nil && !cmd.Flags().Changed(flag) {
+			opts = append(opts, opt)
+		}
+	}
+	if b.Clients != nil {
+		set("clients", bench.WithClients(*b.Clients))
+	}
+	if b.Delay != nil {
+		set("delay", bench.WithDelay(*b.Delay))
+	}
+	if b.QoS != nil {
+		set("qos", bench.WithQoS(*b.QoS))
+	}
+	if b.Topic != nil {
+		set("topic", bench.WithTopic(*b.Topic))
+	}
+	if b.Message != nil {
+		set("message", bench.WithMessage(*b.Message))
+	}
+	if b.Count != nil {
+		set("count", bench.WithMessageCount(*b.Count))
+	}
+	if b.Retain != nil {
+		set("retain", bench.WithRetained(*b.Retain))
+	}
+	return opts
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/rayomqio/benchmq/internal/bench"
+	"github.com/rayomqio/benchmq/internal/scenario"
+	"github.com/rayomqio/benchmq/pkg/logger"
+	"github.com/spf13/cobra"
+)
+
+// runCmd represents the run command
+var runCmd = &cobra.Command{
+	Use:   "run <scenario.yml>",
+	Short: "Run a multi-phase scenario described in a YAML file",
+	Long: `Run a scenario file declaring client groups and the phases they run in.
+
+Groups declare their role (conn, pub, sub), client count, topic, QoS, payload,
+message count and per-client message rate. Phases run in order; a phase marked
+parallel starts together with the phase before it. Groups within a phase run
+concurrently. The final report contains per-phase and per-group statistics.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+		defer signal.Stop(sigs)
+
+		// Parse flags
+		connectConcurrency, err := cmd.Flags().GetInt("connect-concurrency")
+		if err != nil {
+			logger.Error("Failed to parse connect concurrency", logger.ErrorAttr(err))
+			return
+		}
+
+		connectRate, err := cmd.Flags().GetFloat64("connect-rate")
+		if err != nil {
+			logger.Error("Failed to parse connect rate", logger.ErrorAttr(err))
+			return
+		}
+
+		export, err := cmd.Flags().GetString("export")
+		if err != nil {
+			logger.Error("Failed to parse export path", logger.ErrorAttr(err))
+			return
+		}
+
+		s, err := scenario.Load(args[0])
+		if err != nil {
+			logger.Error("Failed to load scenario", logger.State("failed"), logger.ErrorAttr(err))
+			return
+		}
+
+		go func() {
+			<-sigs
+			logger.Info("Received shutdown signal", logger.State("interrupted"))
+			os.Exit(0)
+		}()
+
+		broker, err := brokerOptions(cmd)
+		if err != nil {
+			logger.Error("Failed to parse broker flags", logger.ErrorAttr(err))
+			return
+		}
+
+		opts := []bench.Option{
+			bench.WithConnectConcurrency(connectConcurrency),
+			bench.WithConnectRate(connectRate),
+		}
+		opts = append(opts, broker...)
+		report, err := scenario.Run(Cfg, s, opts...)
+		if err != nil {
+			logger.Error("Failed to run scenario", logger.State("failed"), logger.ErrorAttr(err))
+			return
+		}
+
+		if export != "" {
+			if err := bench.Export(export, report); err != nil {
+				logger.Error("Failed to export report", logger.ErrorAttr(err))
+				return
+			}
+			logger.Info("Exported report", logger.String("path", export))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+
+	// Register flags
+	runCmd.Flags().Int("connect-concurrency", 0, "Maximum in-flight CONNECT attempts per group (0 = unbounded)")
+	runCmd.Flags().Float64("connect-rate", 0, "Maximum new connections per second per group (0 = unlimited)")
+	runCmd.Flags().String("export", "", "Write the JSON report to this file")
+}
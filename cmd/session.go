@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rayomqio/benchmq/internal/bench"
+	"github.com/rayomqio/benchmq/pkg/logger"
+	"github.com/spf13/cobra"
+)
+
+var sessionCmd = &cobra.Command{
+	Use:   "session",
+	Short: "Verify persistent session offline queueing",
+	Long: `Subscribe with clean session off, disconnect the subscribers, publish a backlog and reconnect with the same client IDs to measure what the broker queued.
+
+Parameters:
+	- clientID: Base client ID prefix (subscribers append "-<n>", the publisher "-pub")
+    - clients: Number of persistent session subscribers
+    - count: Number of backlog messages published while the subscribers are offline
+    - topic: Topic the subscribers subscribe to and the backlog is published to
+    - message: Backlog message payload, may be a template
+    - payload-size: Generated backlog payload size instead of message
+    - qos: Quality of service level for the subscriptions and the backlog (1, 2)
+    - timeout: Maximum time a reconnected subscriber waits for its queued messages
+    - keepalive: Keepalive interval in seconds
+    - connect-concurrency: Maximum in-flight CONNECT attempts (0 = unbounded)
+    - connect-rate: Maximum new connections per second (0 = unlimited)
+    - iterations: Number of times to repeat the benchmark
+    - cooldown: Pause between iterations
+    - export: Write the JSON report with per-iteration results to a file
+    - sys-topics: Subscribe a monitor client to $SYS/# and include the broker statistics in the report
+
+Sessions stored for the subscriber client IDs are discarded before and after the run.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+		defer signal.Stop(sigs)
+
+		// Parse flags
+		clientID, err := cmd.Flags().GetString("clientID")
+		if err != nil {
+			logger.Error("Failed to parse client ID", logger.ErrorAttr(err))
+			return
+		}
+
+		clients, err := cmd.Flags().GetInt("clients")
+		if err != nil {
+			logger.Error("Failed to parse number of clients", logger.ErrorAttr(err))
+			return
+		}
+
+		count, err := cmd.Flags().GetInt("count")
+		if err != nil {
+			logger.Error("Failed to parse message count", logger.ErrorAttr(err))
+			return
+		}
+
+		topic, err := cmd.Flags().GetString("topic")
+		if err != nil {
+			logger.Error("Failed to parse topic", logger.ErrorAttr(err))
+			return
+		}
+
+		message, err := cmd.Flags().GetString("message")
+		if err != nil {
+			logger.Error("Failed to parse message", logger.ErrorAttr(err))
+			return
+		}
+
+		payloadSize, err := cmd.Flags().GetString("payload-size")
+		if err != nil {
+			logger.Error("Failed to parse payload size", logger.ErrorAttr(err))
+			return
+		}
+
+		qos, err := cmd.Flags().GetUint16("qos")
+		if err != nil {
+			logger.Error("Failed to parse QoS", logger.ErrorAttr(err))
+			return
+		}
+
+		timeout, err := cmd.Flags().GetDuration("timeout")
+		if err != nil {
+			logger.Error("Failed to parse timeout", logger.ErrorAttr(err))
+			return
+		}
+
+		username, err := cmd.Flags().GetString("username")
+		if err != nil {
+			logger.Error("Failed to parse username", logger.ErrorAttr(err))
+			return
+		}
+
+		password, err := cmd.Flags().GetString("password")
+		if err != nil {
+			logger.Error("Failed to parse password", logger.ErrorAttr(err))
+			return
+		}
+
+		keepalive, err := cmd.Flags().GetUint16("keepalive")
+		if err != nil {
+			logger.Error("Failed to parse keepalive", logger.ErrorAttr(err))
+			return
+		}
+
+		connectConcurrency, err := cmd.Flags().GetInt("connect-concurrency")
+		if err != nil {
+			logger.Error("Failed to parse connect concurrency", logger.ErrorAttr(err))
+			return
+		}
+
+		connectRate, err := cmd.Flags().GetFloat64("connect-rate")
+		if err != nil {
+			logger.Error("Failed to parse connect rate", logger.ErrorAttr(err))
+			return
+		}
+
+		iterations, err := cmd.Flags().GetInt("iterations")
+		if err != nil {
+			logger.Error("Failed to parse iterations", logger.ErrorAttr(err))
+			return
+		}
+
+		cooldown, err := cmd.Flags().GetDuration("cooldown")
+		if err != nil {
+			logger.Error("Failed to parse cooldown", logger.ErrorAttr(err))
+			return
+		}
+
+		export, err := cmd.Flags().GetString("export")
+		if err != nil {
+			logger.Error("Failed to parse export path", logger.ErrorAttr(err))
+			return
+		}
+
+		sysTopics, err := cmd.Flags().GetBool("sys-topics")
+		if err != nil {
+			logger.Error("Failed to parse sys topics flag", logger.ErrorAttr(err))
+			return
+		}
+
+		broker, err := brokerOptions(cmd)
+		if err != nil {
+			logger.Error("Failed to parse broker flags", logger.ErrorAttr(err))
+			return
+		}
+
+		opts := []bench.Option{
+			clientIDOption(cmd, clientID),
+			bench.WithClients(clients),
+			bench.WithMessageCount(count),
+			bench.WithTopic(topic),
+			bench.WithMessage(message),
+			bench.WithPayloadSize(payloadSize),
+			bench.WithQoS(qos),
+			bench.WithSessionTimeout(timeout),
+			ifChanged(cmd, "keepalive", bench.WithKeepAlive(keepalive)),
+			ifChanged(cmd, "username", bench.WithUsername(username)),
+			ifChanged(cmd, "password", bench.WithPassword(password)),
+			bench.WithConnectConcurrency(connectConcurrency),
+			bench.WithConnectRate(connectRate),
+			bench.WithIterations(iterations),
+			bench.WithCooldown(cooldown),
+			bench.WithSysTopics(sysTopics),
+		}
+		opts = append(opts, broker...)
+		b, err := bench.NewBenchmark(Cfg, opts...)
+		if err != nil {
+			logger.Error("Failed to create benchmark", logger.State("failed"), logger.ErrorAttr(err))
+			return
+		}
+
+		go func() {
+			<-sigs
+			logger.Info("Received shutdown signal", logger.State("interrupted"))
+			os.Exit(0)
+		}()
+
+		report := b.Repeat(b.PersistentSession)
+
+		if export != "" {
+			if err := bench.Export(export, report); err != nil {
+				logger.Error("Failed to export report", logger.ErrorAttr(err))
+				return
+			}
+			logger.Info("Exported report", logger.String("path", export))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sessionCmd)
+
+	// Register flags
+	sessionCmd.Flags().StringP("clientID", "i", "benchmq-session", "Client ID for MQTT connections")
+	sessionCmd.Flags().IntP("clients", "c", 10, "Number of persistent session subscribers")
+	sessionCmd.Flags().IntP("count", "n", 1000, "Number of backlog messages published while subscribers are offline")
+	sessionCmd.Flags().StringP("topic", "t", "benchmq/session", "Topic the subscribers subscribe to and the backlog is published to")
+	sessionCmd.Flags().StringP("message", "m", "Hello, World!", "Backlog message payload, may be a text/template")
+	sessionCmd.Flags().String("payload-size", "", "Generated backlog payload size: fixed (1KB), range (512-4096) or weighted (1KB:3,64KB:1)")
+	sessionCmd.Flags().Uint16P("qos", "q", 1, "Quality of service level (1, 2)")
+	sessionCmd.Flags().Duration("timeout", 30*time.Second, "Maximum time a reconnected subscriber waits for its queued messages")
+	sessionCmd.Flags().Uint16P("keepalive", "k", 60, "Keepalive interval in seconds")
+	sessionCmd.Flags().StringP("username", "u", "", "Username for MQTT connections")
+	sessionCmd.Flags().StringP("password", "p", "", "Password for MQTT connections")
+	sessionCmd.Flags().Int("connect-concurrency", 0, "Maximum in-flight CONNECT attempts (0 = unbounded)")
+	sessionCmd.Flags().Float64("connect-rate", 0, "Maximum new connections per second (0 = unlimited)")
+	sessionCmd.Flags().Int("iterations", 1, "Number of times to repeat the benchmark")
+	sessionCmd.Flags().Duration("cooldown", 0, "Pause between iterations (e.g. 10s)")
+	sessionCmd.Flags().String("export", "", "Write the JSON report to this file")
+	sessionCmd.Flags().Bool("sys-topics", false, "Record the broker's $SYS statistics during each run")
+}
@@ -19,9 +19,28 @@ Parameters:
 	- clientID: Base client ID prefix (each client appends "-<n>")
     - clients: Number of concurrent subscribers
     - qos: Quality of service level (0, 1, 2)
-    - topic: Topic to subscribe to
+    - topic: Topic to subscribe to, rendered once per client when it contains {{ }} template actions
     - clean: Whether to use a clean session
     - keepalive: Keepalive interval in seconds
+    - connect-concurrency: Maximum in-flight CONNECT attempts (0 = unbounded)
+    - connect-rate: Maximum new connections per second (0 = unlimited)
+    - iterations: Number of times to repeat the benchmark
+    - cooldown: Pause between iterations
+    - export: Write the JSON report with per-iteration results to a file
+    - sys-topics: Subscribe a monitor client to $SYS/# and include the broker statistics in the report
+    - probe-topic: Topic template the built-in probe publisher renders per probe topic
+    - probe-topics: Number of distinct probe topics
+    - probe-count: Probe messages per topic; subscribers verify filter matches and completeness
+    - share-group: Subscribe through $share/<group>/<topic> and report per-member distribution
+    - share-groups: Spread clients round-robin over this many share groups named <group>-<n>
+    - subscriptions: Topics each client subscribes to, rendered from the topic template with .Sub = 0..subscriptions-1
+    - single-subscribe: Send all of a client's subscriptions in one SUBSCRIBE packet
+    - publishers: Tagged publishers every subscriber expects; ones that deliver nothing count as lost (0 = only publishers seen)
+    - checksum: Integrity header every payload must carry (crc32, xxhash); payloads without one count as truncated or corrupted
+    - process-time: Time slow subscribers spend per message: fixed (10ms), range (5ms-50ms) or exponential (exp:10ms)
+    - slow-clients: Subscribers that process slowly, the rest stay fast (0 = all)
+    - queue-size: Bounded processing queue per slow subscriber; a full queue stalls reading from the broker
+    - queue-drop: Drop messages when the processing queue is full instead of stalling
     - delay: Optional sleep between subscription lifetime checks
     - count: Expected number of messages (used to determine how long to wait)`,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -90,19 +109,152 @@ Parameters:
 			return
 		}
 
-		b, err := bench.NewBenchmark(
-			Cfg,
-			bench.WithClientID(clientID),
+		connectConcurrency, err := cmd.Flags().GetInt("connect-concurrency")
+		if err != nil {
+			logger.Error("Failed to parse connect concurrency", logger.ErrorAttr(err))
+			return
+		}
+
+		connectRate, err := cmd.Flags().GetFloat64("connect-rate")
+		if err != nil {
+			logger.Error("Failed to parse connect rate", logger.ErrorAttr(err))
+			return
+		}
+
+		iterations, err := cmd.Flags().GetInt("iterations")
+		if err != nil {
+			logger.Error("Failed to parse iterations", logger.ErrorAttr(err))
+			return
+		}
+
+		cooldown, err := cmd.Flags().GetDuration("cooldown")
+		if err != nil {
+			logger.Error("Failed to parse cooldown", logger.ErrorAttr(err))
+			return
+		}
+
+		export, err := cmd.Flags().GetString("export")
+		if err != nil {
+			logger.Error("Failed to parse export path", logger.ErrorAttr(err))
+			return
+		}
+
+		sysTopics, err := cmd.Flags().GetBool("sys-topics")
+		if err != nil {
+			logger.Error("Failed to parse sys topics flag", logger.ErrorAttr(err))
+			return
+		}
+
+		probeTopic, err := cmd.Flags().GetString("probe-topic")
+		if err != nil {
+			logger.Error("Failed to parse probe topic", logger.ErrorAttr(err))
+			return
+		}
+
+		probeTopics, err := cmd.Flags().GetInt("probe-topics")
+		if err != nil {
+			logger.Error("Failed to parse probe topics", logger.ErrorAttr(err))
+			return
+		}
+
+		probeCount, err := cmd.Flags().GetInt("probe-count")
+		if err != nil {
+			logger.Error("Failed to parse probe count", logger.ErrorAttr(err))
+			return
+		}
+
+		shareGroup, err := cmd.Flags().GetString("share-group")
+		if err != nil {
+			logger.Error("Failed to parse share group", logger.ErrorAttr(err))
+			return
+		}
+
+		shareGroups, err := cmd.Flags().GetInt("share-groups")
+		if err != nil {
+			logger.Error("Failed to parse share groups", logger.ErrorAttr(err))
+			return
+		}
+
+		subscriptions, err := cmd.Flags().GetInt("subscriptions")
+		if err != nil {
+			logger.Error("Failed to parse subscriptions", logger.ErrorAttr(err))
+			return
+		}
+
+		singleSubscribe, err := cmd.Flags().GetBool("single-subscribe")
+		if err != nil {
+			logger.Error("Failed to parse single subscribe flag", logger.ErrorAttr(err))
+			return
+		}
+
+		publishers, err := cmd.Flags().GetInt("publishers")
+		if err != nil {
+			logger.Error("Failed to parse publishers", logger.ErrorAttr(err))
+			return
+		}
+
+		checksum, err := cmd.Flags().GetString("checksum")
+		if err != nil {
+			logger.Error("Failed to parse checksum", logger.ErrorAttr(err))
+			return
+		}
+
+		processTime, err := cmd.Flags().GetString("process-time")
+		if err != nil {
+			logger.Error("Failed to parse process time", logger.ErrorAttr(err))
+			return
+		}
+
+		slowClients, err := cmd.Flags().GetInt("slow-clients")
+		if err != nil {
+			logger.Error("Failed to parse slow clients", logger.ErrorAttr(err))
+			return
+		}
+
+		queueSize, err := cmd.Flags().GetInt("queue-size")
+		if err != nil {
+			logger.Error("Failed to parse queue size", logger.ErrorAttr(err))
+			return
+		}
+
+		queueDrop, err := cmd.Flags().GetBool("queue-drop")
+		if err != nil {
+			logger.Error("Failed to parse queue drop flag", logger.ErrorAttr(err))
+			return
+		}
+
+		broker, err := brokerOptions(cmd)
+		if err != nil {
+			logger.Error("Failed to parse broker flags", logger.ErrorAttr(err))
+			return
+		}
+
+		opts := []bench.Option{
+			clientIDOption(cmd, clientID),
 			bench.WithClients(clients),
 			bench.WithTopic(topic),
 			bench.WithQoS(qos),
 			bench.WithMessageCount(count),
 			bench.WithDelay(delay),
-			bench.WithCleanSession(cleanSession),
-			bench.WithKeepAlive(keepalive),
-			bench.WithUsername(username),
-			bench.WithPassword(password),
-		)
+			ifChanged(cmd, "clean", bench.WithCleanSession(cleanSession)),
+			ifChanged(cmd, "keepalive", bench.WithKeepAlive(keepalive)),
+			ifChanged(cmd, "username", bench.WithUsername(username)),
+			ifChanged(cmd, "password", bench.WithPassword(password)),
+			bench.WithConnectConcurrency(connectConcurrency),
+			bench.WithConnectRate(connectRate),
+			bench.WithIterations(iterations),
+			bench.WithCooldown(cooldown),
+			bench.WithSysTopics(sysTopics),
+			bench.WithProbe(probeTopic, probeTopics, probeCount),
+			bench.WithShareGroup(shareGroup, shareGroups),
+			bench.WithSubscriptions(subscriptions, singleSubscribe),
+			bench.WithPublishers(publishers),
+			bench.WithChecksum(bench.Checksum(checksum)),
+			bench.WithProcessing(processTime, slowClients, queueSize, queueDrop),
+		}
+		opts = append(opts, broker...)
+		opts = append(opts, configOptions(cmd, Cfg.Sub)...)
+		b, err := bench.NewBenchmark(Cfg, opts...)
 		if err != nil {
 			logger.Error("Failed to create benchmark", logger.State("failed"), logger.ErrorAttr(err))
 			return
@@ -114,7 +266,15 @@ Parameters:
 			os.Exit(0)
 		}()
 
-		b.Subscribe()
+		report := b.Repeat(b.Subscribe)
+
+		if export != "" {
+			if err := bench.Export(export, report); err != nil {
+				logger.Error("Failed to export report", logger.ErrorAttr(err))
+				return
+			}
+			logger.Info("Exported report", logger.String("path", export))
+		}
 	},
 }
 
@@ -127,9 +287,28 @@ func init() {
 	subCmd.Flags().IntP("delay", "d", 1000, "Delay between subscription lifetime checks (ms)")
 	subCmd.Flags().IntP("count", "n", 1000, "Expected number of messages per client")
 	subCmd.Flags().Uint16P("qos", "q", 0, "Quality of service level (0, 1, 2)")
-	subCmd.Flags().StringP("topic", "t", "benchmq", "Topic to subscribe to")
+	subCmd.Flags().StringP("topic", "t", "benchmq", "Topic to subscribe to, may be a text/template rendered per client")
 	subCmd.Flags().BoolP("clean", "x", true, "Clean previous session when connecting")
 	subCmd.Flags().Uint16P("keepalive", "k", 60, "Keepalive interval in seconds")
 	subCmd.Flags().StringP("username", "u", "", "Username for MQTT connections")
 	subCmd.Flags().StringP("password", "p", "", "Password for MQTT connections")
+	subCmd.Flags().Int("connect-concurrency", 0, "Maximum in-flight CONNECT attempts (0 = unbounded)")
+	subCmd.Flags().Float64("connect-rate", 0, "Maximum new connections per second (0 = unlimited)")
+	subCmd.Flags().Int("iterations", 1, "Number of times to repeat the benchmark")
+	subCmd.Flags().Duration("cooldown", 0, "Pause between iterations (e.g. 10s)")
+	subCmd.Flags().String("export", "", "Write the JSON report to this file")
+	subCmd.Flags().Bool("sys-topics", false, "Record the broker's $SYS statistics during each run")
+	subCmd.Flags().String("probe-topic", "", "Probe topic template, rendered with .ClientIndex = 0..probe-topics-1 (default: topic)")
+	subCmd.Flags().Int("probe-topics", 1, "Number of distinct probe topics")
+	subCmd.Flags().Int("probe-count", 0, "Probe messages per probe topic; enables delivery verification")
+	subCmd.Flags().String("share-group", "", "Shared subscription group name; clients subscribe to $share/<group>/<topic>")
+	subCmd.Flags().Int("share-groups", 1, "Number of share groups clients are spread over round-robin")
+	subCmd.Flags().Int("subscriptions", 1, "Topics each client subscribes to, rendered from the topic template with .Sub")
+	subCmd.Flags().Bool("single-subscribe", false, "Send all of a client's subscriptions in a single SUBSCRIBE packet")
+	subCmd.Flags().String("checksum", "", "Integrity header every payload must carry: crc32 or xxhash (empty = verify only payloads that carry one)")
+	subCmd.Flags().Int("publishers", 0, "Tagged publishers every subscriber expects; ones that deliver nothing count as lost (0 = only publishers seen)")
+	subCmd.Flags().String("process-time", "", "Per-message processing time of slow subscribers: 10ms, 5ms-50ms or exp:10ms")
+	subCmd.Flags().Int("slow-clients", 0, "Subscribers that process slowly, the rest stay fast (0 = all)")
+	subCmd.Flags().Int("queue-size", 100, "Processing queue per slow subscriber")
+	subCmd.Flags().Bool("queue-drop", false, "Drop messages when the processing queue is full instead of stalling the connection")
 }
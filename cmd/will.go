@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rayomqio/benchmq/internal/bench"
+	"github.com/rayomqio/benchmq/pkg/logger"
+	"github.com/spf13/cobra"
+)
+
+var willCmd = &cobra.Command{
+	Use:   "will",
+	Short: "Benchmark last will and testament delivery after ungraceful disconnects",
+	Long: `Connect clients with a will, drop their TCP connections without a DISCONNECT and measure how quickly and completely monitors receive the wills.
+
+Parameters:
+	- clientID: Base client ID prefix (clients append "-<n>", monitors "-monitor-<n>")
+    - clients: Number of clients with a will that are killed
+    - delay: Delay between kills in milliseconds
+    - will-topic: Will topic, may be a template rendered per client (default: client.will.topic from config)
+    - will-payload: Will payload, may be a template rendered per client
+    - will-qos: Will QoS level (0, 1, 2)
+    - will-retain: Publish the will as a retained message; the will topics are cleared afterwards
+    - filter: Subscription filter used by the monitors
+    - monitors: Number of monitor clients subscribed to the will topics
+    - qos: Monitor subscription QoS level (0, 1, 2)
+    - timeout: Maximum time to wait for the wills after the last kill
+    - keepalive: Keepalive interval in seconds
+    - connect-concurrency: Maximum in-flight CONNECT attempts (0 = unbounded)
+    - connect-rate: Maximum new connections per second (0 = unlimited)
+    - iterations: Number of times to repeat the benchmark
+    - cooldown: Pause between iterations
+    - export: Write the JSON report with per-iteration results to a file
+    - sys-topics: Subscribe a monitor client to $SYS/# and include the broker statistics in the report
+
+Each client's will is identified by its topic and payload, so at least one of them must differ per client; runs where two
+clients render the same will are rejected.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+		defer signal.Stop(sigs)
+
+		// Parse flags
+		clientID, err := cmd.Flags().GetString("clientID")
+		if err != nil {
+			logger.Error("Failed to parse client ID", logger.ErrorAttr(err))
+			return
+		}
+
+		clients, err := cmd.Flags().GetInt("clients")
+		if err != nil {
+			logger.Error("Failed to parse number of clients", logger.ErrorAttr(err))
+			return
+		}
+
+		delay, err := cmd.Flags().GetInt("delay")
+		if err != nil {
+			logger.Error("Failed to parse delay", logger.ErrorAttr(err))
+			return
+		}
+
+		willTopic, err := cmd.Flags().GetString("will-topic")
+		if err != nil {
+			logger.Error("Failed to parse will topic", logger.ErrorAttr(err))
+			return
+		}
+
+		willPayload, err := cmd.Flags().GetString("will-payload")
+		if err != nil {
+			logger.Error("Failed to parse will payload", logger.ErrorAttr(err))
+			return
+		}
+
+		willQoS, err := cmd.Flags().GetUint16("will-qos")
+		if err != nil {
+			logger.Error("Failed to parse will QoS", logger.ErrorAttr(err))
+			return
+		}
+
+		willRetain, err := cmd.Flags().GetBool("will-retain")
+		if err != nil {
+			logger.Error("Failed to parse will retain flag", logger.ErrorAttr(err))
+			return
+		}
+
+		filter, err := cmd.Flags().GetString("filter")
+		if err != nil {
+			logger.Error("Failed to parse filter", logger.ErrorAttr(err))
+			return
+		}
+
+		monitors, err := cmd.Flags().GetInt("monitors")
+		if err != nil {
+			logger.Error("Failed to parse monitors", logger.ErrorAttr(err))
+			return
+		}
+
+		qos, err := cmd.Flags().GetUint16("qos")
+		if err != nil {
+			logger.Error("Failed to parse QoS", logger.ErrorAttr(err))
+			return
+		}
+
+		timeout, err := cmd.Flags().GetDuration("timeout")
+		if err != nil {
+			logger.Error("Failed to parse timeout", logger.ErrorAttr(err))
+			return
+		}
+
+		username, err := cmd.Flags().GetString("username")
+		if err != nil {
+			logger.Error("Failed to parse username", logger.ErrorAttr(err))
+			return
+		}
+
+		password, err := cmd.Flags().GetString("password")
+		if err != nil {
+			logger.Error("Failed to parse password", logger.ErrorAttr(err))
+			return
+		}
+
+		keepalive, err := cmd.Flags().GetUint16("keepalive")
+		if err != nil {
+			logger.Error("Failed to parse keepalive", logger.ErrorAttr(err))
+			return
+		}
+
+		connectConcurrency, err := cmd.Flags().GetInt("connect-concurrency")
+		if err != nil {
+			logger.Error("Failed to parse connect concurrency", logger.ErrorAttr(err))
+			return
+		}
+
+		connectRate, err := cmd.Flags().GetFloat64("connect-rate")
+		if err != nil {
+			logger.Error("Failed to parse connect rate", logger.ErrorAttr(err))
+			return
+		}
+
+		iterations, err := cmd.Flags().GetInt("iterations")
+		if err != nil {
+			logger.Error("Failed to parse iterations", logger.ErrorAttr(err))
+			return
+		}
+
+		cooldown, err := cmd.Flags().GetDuration("cooldown")
+		if err != nil {
+			logger.Error("Failed to parse cooldown", logger.ErrorAttr(err))
+			return
+		}
+
+		export, err := cmd.Flags().GetString("export")
+		if err != nil {
+			logger.Error("Failed to parse export path", logger.ErrorAttr(err))
+			return
+		}
+
+		sysTopics, err := cmd.Flags().GetBool("sys-topics")
+		if err != nil {
+			logger.Error("Failed to parse sys topics flag", logger.ErrorAttr(err))
+			return
+		}
+
+		// The will from the config file applies unless a will flag is given
+		will := bench.WithWill(willTopic, willPayload, byte(willQoS), willRetain)
+		if Cfg.Client.Will.Topic != "" && !cmd.Flags().Changed("will-topic") && !cmd.Flags().Changed("will-payload") &&
+			!cmd.Flags().Changed("will-qos") && !cmd.Flags().Changed("will-retain") {
+			will = nil
+		}
+
+		broker, err := brokerOptions(cmd)
+		if err != nil {
+			logger.Error("Failed to parse broker flags", logger.ErrorAttr(err))
+			return
+		}
+
+		opts := []bench.Option{
+			clientIDOption(cmd, clientID),
+			bench.WithClients(clients),
+			bench.WithDelay(delay),
+			will,
+			bench.WithWillMonitors(filter, monitors, timeout),
+			bench.WithQoS(qos),
+			ifChanged(cmd, "keepalive", bench.WithKeepAlive(keepalive)),
+			ifChanged(cmd, "username", bench.WithUsername(username)),
+			ifChanged(cmd, "password", bench.WithPassword(password)),
+			bench.WithConnectConcurrency(connectConcurrency),
+			bench.WithConnectRate(connectRate),
+			bench.WithIterations(iterations),
+			bench.WithCooldown(cooldown),
+			bench.WithSysTopics(sysTopics),
+		}
+		opts = append(opts, broker...)
+		b, err := bench.NewBenchmark(Cfg, opts...)
+		if err != nil {
+			logger.Error("Failed to create benchmark", logger.State("failed"), logger.ErrorAttr(err))
+			return
+		}
+
+		go func() {
+			<-sigs
+			logger.Info("Received shutdown signal", logger.State("interrupted"))
+			os.Exit(0)
+		}()
+
+		report := b.Repeat(b.WillDelivery)
+
+		if export != "" {
+			if err := bench.Export(export, report); err != nil {
+				logger.Error("Failed to export report", logger.ErrorAttr(err))
+				return
+			}
+			logger.Info("Exported report", logger.String("path", export))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(willCmd)
+
+	// Register flags
+	willCmd.Flags().StringP("clientID", "i", "benchmq-will", "Client ID for MQTT connections")
+	willCmd.Flags().IntP("clients", "c", 100, "Number of clients with a will that are killed")
+	willCmd.Flags().IntP("delay", "d", 0, "Delay between kills in milliseconds")
+	willCmd.Flags().String("will-topic", "benchmq/will/{{.ClientID}}", "Will topic, may be a text/template rendered per client")
+	willCmd.Flags().String("will-payload", "offline", "Will payload, may be a text/template rendered per client")
+	willCmd.Flags().Uint16("will-qos", 1, "Will QoS level (0, 1, 2)")
+	willCmd.Flags().Bool("will-retain", false, "Publish the will as a retained message")
+	willCmd.Flags().StringP("filter", "f", "benchmq/will/#", "Subscription filter used by the monitors")
+	willCmd.Flags().Int("monitors", 1, "Number of monitor clients subscribed to the will topics")
+	willCmd.Flags().Uint16P("qos", "q", 1, "Monitor subscription QoS level (0, 1, 2)")
+	willCmd.Flags().Duration("timeout", 30*time.Second, "Maximum time to wait for the wills after the last kill")
+	willCmd.Flags().Uint16P("keepalive", "k", 60, "Keepalive interval in seconds")
+	willCmd.Flags().StringP("username", "u", "", "Username for MQTT connections")
+	willCmd.Flags().StringP("password", "p", "", "Password for MQTT connections")
+	willCmd.Flags().Int("connect-concurrency", 0, "Maximum in-flight CONNECT attempts (0 = unbounded)")
+	willCmd.Flags().Float64("connect-rate", 0, "Maximum new connections per second (0 = unlimited)")
+	willCmd.Flags().Int("iterations", 1, "Number of times to repeat the benchmark")
+	willCmd.Flags().Duration("cooldown", 0, "Pause between iterations (e.g. 10s)")
+	willCmd.Flags().String("export", "", "Write the JSON report to this file")
+	willCmd.Flags().Bool("sys-topics", false, "Record the broker's $SYS statistics during each run")
+}
@@ -0,0 +1,82 @@
+package bench
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// Arrival names the inter-arrival distribution used between publishes
+type Arrival string
+
+const (
+	ArrivalConstant Arrival = "constant" // Fixed delay between messages
+	ArrivalPoisson  Arrival = "poisson"  // Exponentially distributed delay
+	ArrivalUniform  Arrival = "uniform"  // Delay with uniform jitter around the mean
+	ArrivalBurst    Arrival = "burst"    // Bursts of messages separated by a pause
+)
+
+// pacer yields the wait before each publish of a single client.
+// Every distribution keeps the mean interval at the configured delay.
+type pacer struct {
+	arrival Arrival
+	mean    time.Duration
+	jitter  time.Duration
+	burst   int
+	rng     *rand.Rand
+	sent    int
+}
+
+// newPacer creates the pacer of the client with the given index. The client
+// index is mixed into the seed so clients don't publish in lockstep.
+func (b *Bench) newPacer(index int) *pacer {
+	return &pacer{
+		arrival: b.arrival,
+		mean:    b.meanDelay(),
+		jitter:  time.Duration(b.jitter) * time.Millisecond,
+		burst:   b.burstSize,
+		rng:     newRand(b.seed, streamPacer, index),
+	}
+}
+
+// next returns how long to wait before the next message
+func (p *pacer) next() time.Duration {
+	defer func() { p.sent++ }()
+
+	switch p.arrival {
+	case ArrivalPoisson:
+		return time.Duration(p.rng.ExpFloat64() * float64(p.mean))
+	case ArrivalUniform:
+		jitter := p.jitter
+		if jitter == 0 {
+			jitter = p.mean
+		}
+		return p.mean + time.Duration((p.rng.Float64()*2-1)*float64(jitter))
+	case ArrivalBurst:
+		if p.sent%p.burst == 0 {
+			return p.mean * time.Duration(p.burst)
+		}
+		return 0
+	default:
+		return p.mean
+	}
+}
+
+// describeArrival renders the arrival settings for the report
+func (b *Bench) describeArrival() string {
+	mean := b.meanDelay()
+	switch b.arrival {
+	case ArrivalUniform:
+		jitter := time.Duration(b.jitter) * time.Millisecond
+		if jitter == 0 {
+			jitter = mean
+		}
+		return fmt.Sprintf("uniform(mean=%s, jitter=%s, seed=%d)", mean, jitter, b.seed)
+	case ArrivalBurst:
+		return fmt.Sprintf("burst(mean=%s, size=%d, seed=%d)", mean, b.burstSize, b.seed)
+	case ArrivalPoisson:
+		return fmt.Sprintf("poisson(mean=%s, seed=%d)", mean, b.seed)
+	default:
+		return fmt.Sprintf("constant(delay=%s)", mean)
+	}
+}
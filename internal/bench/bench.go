@@ -1,8 +1,14 @@
 package bench
 
 import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
 	"sync"
+	"text/template"
+	"time"
 
+	"github.com/rayomqio/benchmq/internal/mqtt"
 	"github.com/rayomqio/benchmq/pkg/config"
 	"github.com/rayomqio/benchmq/pkg/er"
 	"github.com/rayomqio/benchmq/pkg/logger"
@@ -13,6 +19,7 @@ type QoSLevel uint8
 // Bench represents the benchmark fields
 type Bench struct {
 	delay        int
+	interval     time.Duration // Overrides delay with a finer mean interval
 	clients      int
 	clientID     string
 	topic        string
@@ -26,13 +33,104 @@ type Bench struct {
 	port         uint16
 	username     string
 	password     string
-	wg           sync.WaitGroup // Wait Group
-	cfg          *config.Config // Config
-	logger       *logger.Logger // Logger
+	// Connection pacing
+	connectConcurrency int
+	connectRate        float64
+	// Connections held open while keepalive pings are measured
+	hold       time.Duration
+	pingWindow time.Duration
+	// Unacknowledged publishes allowed per client
+	maxInflight    int
+	publishTimeout time.Duration
+	// Publish arrival pattern
+	arrival   Arrival
+	jitter    int
+	burstSize int
+	seed      int64
+	// Warm-up excluded from the publish summary
+	warmup      time.Duration
+	warmupCount int
+	// Repeated runs
+	iterations int
+	cooldown   time.Duration
+	sysTopics  bool // Record the broker's $SYS statistics during each run
+	// Generated payloads, the message is used when no size is set
+	payloadSizeSpec string
+	payloadSize     *PayloadSize
+	payloadContent  PayloadContent
+	messageFile     string
+	messageTemplate *template.Template
+	// Replayed payload corpus
+	payloadFile   string
+	payloadFormat CorpusFormat
+	payloadDir    string
+	corpusOrder   CorpusOrder
+	corpus        [][]byte
+	// Per-client topic template
+	topicTemplate *template.Template
+	sequenceTag   bool     // Prefix payloads with publisher ID and sequence
+	publishers    int      // Tagged publishers subscribers expect, seen or not
+	checksum      Checksum // Prefix payloads with an integrity header
+	// Probe messages verified by subscribers
+	probeTopic    string
+	probeTopics   int
+	probeCount    int
+	probeTemplate *template.Template
+	// Shared subscription groups
+	shareGroup  string
+	shareGroups int
+	// Subscriptions per client
+	subscriptions   int
+	singleSubscribe bool
+	// Retained message benchmark
+	retainedFilter  string
+	retainedTimeout time.Duration
+	retainedCleanup bool
+	// Last will and testament, defaulting to the config file
+	willTopic           string
+	willPayload         string
+	willQoS             byte
+	willRetain          bool
+	willTopicTemplate   *template.Template
+	willPayloadTemplate *template.Template
+	willFilter          string
+	willMonitors        int
+	willTimeout         time.Duration
+	willDelivery        bool          // Will benchmark run, which needs a distinct will per client
+	wills               []config.Will // Rendered wills of a will benchmark run
+	// Persistent session offline queue
+	sessionTimeout time.Duration
+	// Simulated message processing by slow subscribers
+	processTimeSpec string
+	processTime     *ProcessTime
+	slowClients     int // Subscribers that process slowly, 0 = all of them
+	queueSize       int
+	queueDrop       bool
+
+	wg     sync.WaitGroup // Wait Group
+	cfg    *config.Config // Config
+	logger *logger.Logger // Logger
 }
 
 type Option func(*Bench)
 
+// Random stream constants, mixed with the client index so every consumer of
+// the run seed draws from its own stream
+const (
+	streamPacer    uint64 = 0
+	streamPayloads uint64 = 0x9e3779b97f4a7c15
+	streamTopics   uint64 = 0x5851f42d4c957f2d
+	streamBuffer   uint64 = 0xbf58476d1ce4e5b9
+	streamWill     uint64 = 0x94d049bb133111eb
+	streamProcess  uint64 = 0xd6e8feb86659fd93
+	streamProbe    uint64 = 0xa0761d6478bd642f
+)
+
+// newRand returns the random stream of a consumer for the client with the given index
+func newRand(seed int64, stream uint64, index int) *rand.Rand {
+	return rand.New(rand.NewPCG(uint64(seed), uint64(index)^stream))
+}
+
 const (
 	QoS0 QoSLevel = 0 // QoS At Most Once
 	QoS1 QoSLevel = 1 // QoS At Least Once
@@ -40,16 +138,33 @@ const (
 )
 
 const (
-	DefaultDelay        = 1000             // Default delay between connection (ms)
-	DefaultClients      = 100              // Default clients to connect
-	DefaultClientID     = "benchmq-client" // Default client id
-	DefaultTopic        = "bench/test"     // Default publish/subscribe topic
-	DefaultCleanSession = true             // Default clean session state
-	DefaultQoS          = QoS0             // Default QoS level
-	DefaultKeepAlive    = 60               // Default connection keep alive
-	DefaultMessageCount = 100              // Default message count
-	DefaultMessage      = "Hello, World!"  // Default message
-	DefaultRetained     = false            // Default retained message state
+	DefaultDelay           = 1000                       // Default delay between connection (ms)
+	DefaultClients         = 100                        // Default clients to connect
+	DefaultClientID        = "benchmq-client"           // Default client id
+	DefaultTopic           = "bench/test"               // Default publish/subscribe topic
+	DefaultCleanSession    = true                       // Default clean session state
+	DefaultQoS             = QoS0                       // Default QoS level
+	DefaultKeepAlive       = 60                         // Default connection keep alive
+	DefaultMessageCount    = 100                        // Default message count
+	DefaultMessage         = "Hello, World!"            // Default message
+	DefaultRetained        = false                      // Default retained message state
+	DefaultArrival         = ArrivalConstant            // Default inter-arrival distribution
+	DefaultBurstSize       = 1                          // Default messages per burst
+	DefaultMaxInflight     = 1                          // Default unacknowledged publishes per client
+	DefaultPublishTimeout  = mqtt.DefaultPublishTimeout // Default wait for a publish acknowledgement
+	DefaultIterations      = 1                          // Default benchmark repetitions
+	DefaultContent         = ContentRandom              // Default generated payload content
+	DefaultFormat          = FormatLines                // Default payload file framing
+	DefaultOrder           = OrderSequential            // Default corpus replay order
+	DefaultSubscriptions   = 1                          // Default subscriptions per client
+	DefaultRetainedFilter  = "#"                        // Default retained subscription filter
+	DefaultRetainedTimeout = 30 * time.Second           // Default wait for retained delivery
+	DefaultWillFilter      = "#"                        // Default will monitor filter
+	DefaultWillMonitors    = 1                          // Default will monitors
+	DefaultWillTimeout     = 30 * time.Second           // Default wait for will delivery
+	DefaultSessionTimeout  = 30 * time.Second           // Default wait for queued messages
+	DefaultQueueSize       = 100                        // Default processing queue per slow subscriber
+	DefaultPingWindow      = 10 * time.Second           // Default window of the ping round-trip distribution
 )
 
 // NewBenchmark constructor initializes the bench struct
@@ -64,20 +179,44 @@ func NewBenchmark(cfg *config.Config, options ...Option) (*Bench, error) {
 	}
 
 	bench := Bench{
-		delay:        DefaultDelay,
-		clients:      DefaultClients,
-		clientID:     DefaultClientID,
-		topic:        DefaultTopic,
-		message:      DefaultMessage,
-		messageCount: DefaultMessageCount,
-		retained:     DefaultRetained,
-		cleanSession: &cfg.Client.CleanSession,
-		qos:          DefaultQoS,
-		keepAlive:    cfg.Client.KeepAlive,
-		host:         cfg.Server.Host,
-		port:         cfg.Server.Port,
-		cfg:          cfg,
-		logger:       logger.NewBenchmarkLogger("Benchmark"),
+		delay:           DefaultDelay,
+		clients:         DefaultClients,
+		clientID:        cfg.Client.ClientID,
+		topic:           DefaultTopic,
+		message:         DefaultMessage,
+		messageCount:    DefaultMessageCount,
+		retained:        DefaultRetained,
+		cleanSession:    &cfg.Client.CleanSession,
+		qos:             DefaultQoS,
+		arrival:         DefaultArrival,
+		burstSize:       DefaultBurstSize,
+		maxInflight:     DefaultMaxInflight,
+		publishTimeout:  DefaultPublishTimeout,
+		iterations:      DefaultIterations,
+		payloadContent:  DefaultContent,
+		payloadFormat:   DefaultFormat,
+		corpusOrder:     DefaultOrder,
+		subscriptions:   DefaultSubscriptions,
+		retainedFilter:  DefaultRetainedFilter,
+		retainedTimeout: DefaultRetainedTimeout,
+		retainedCleanup: true,
+		willTopic:       cfg.Client.Will.Topic,
+		willPayload:     cfg.Client.Will.Payload,
+		willQoS:         cfg.Client.Will.QoS,
+		willRetain:      cfg.Client.Will.Retain,
+		willFilter:      DefaultWillFilter,
+		willMonitors:    DefaultWillMonitors,
+		willTimeout:     DefaultWillTimeout,
+		sessionTimeout:  DefaultSessionTimeout,
+		queueSize:       DefaultQueueSize,
+		pingWindow:      DefaultPingWindow,
+		keepAlive:       cfg.Client.KeepAlive,
+		username:        cfg.Client.Username,
+		password:        cfg.Client.Password,
+		host:            cfg.Server.Host,
+		port:            cfg.Server.Port,
+		cfg:             cfg,
+		logger:          logger.NewBenchmarkLogger("Benchmark"),
 	}
 
 	for _, option := range options {
@@ -89,12 +228,55 @@ func NewBenchmark(cfg *config.Config, options ...Option) (*Bench, error) {
 	if err := bench.validate(); err != nil {
 		return nil, err
 	}
+	if err := bench.prepare(); err != nil {
+		return nil, err
+	}
+
+	// Clients are created from the config, so it has to name the benchmark's broker
+	if bench.host != cfg.Server.Host || bench.port != cfg.Server.Port {
+		c := *cfg
+		c.Server.Host = bench.host
+		c.Server.Port = bench.port
+		bench.cfg = &c
+	}
 
 	return &bench, nil
 }
 
-// Validate checks semantic correctness of the benchmark configuration
+// Validate checks semantic correctness of the benchmark configuration. It
+// only checks the settings; loading what they refer to is left to prepare.
 func (b *Bench) validate() error {
+	for _, check := range []func() error{
+		b.validateConnection,
+		b.validatePacing,
+		b.validatePayloads,
+		b.validateSubscriptions,
+		b.validateWills,
+		b.validateProcessing,
+	} {
+		if err := check(); err != nil {
+			return err
+		}
+	}
+	// Set default clientID
+	if b.clientID == "" {
+		b.clientID = DefaultClientID
+	}
+	if b.keepAlive == 0 {
+		b.keepAlive = DefaultKeepAlive
+	}
+	if b.cleanSession == nil {
+		cs := true
+		b.cleanSession = &cs
+	}
+	if b.probeCount > 0 && b.probeTopics == 0 {
+		b.probeTopics = 1
+	}
+	return nil
+}
+
+// validateConnection checks the clients, broker and run settings
+func (b *Bench) validateConnection() error {
 	if b.clients <= 0 {
 		return &er.Error{
 			Package: "Bench",
@@ -103,14 +285,6 @@ func (b *Bench) validate() error {
 			Raw:     er.ErrInvalidClients,
 		}
 	}
-	if b.delay < 0 {
-		return &er.Error{
-			Package: "Bench",
-			Func:    "Validate",
-			Message: er.ErrInvalidDelay,
-			Raw:     er.ErrInvalidDelay,
-		}
-	}
 	if b.host == "" {
 		return &er.Error{
 			Package: "Bench",
@@ -135,6 +309,22 @@ func (b *Bench) validate() error {
 			Raw:     er.ErrInvalidPort,
 		}
 	}
+	if b.connectConcurrency < 0 {
+		return &er.Error{
+			Package: "Bench",
+			Func:    "Validate",
+			Message: er.ErrInvalidConnectConcurrency,
+			Raw:     er.ErrInvalidConnectConcurrency,
+		}
+	}
+	if b.connectRate < 0 {
+		return &er.Error{
+			Package: "Bench",
+			Func:    "Validate",
+			Message: er.ErrInvalidConnectRate,
+			Raw:     er.ErrInvalidConnectRate,
+		}
+	}
 	if b.qos > QoS2 {
 		return &er.Error{
 			Package: "Bench",
@@ -143,26 +333,248 @@ func (b *Bench) validate() error {
 			Raw:     er.ErrInvalidQoS,
 		}
 	}
-	// Set default clientID
-	if b.clientID == "" {
-		b.clientID = DefaultClientID
+	if b.hold < 0 || b.pingWindow <= 0 {
+		return &er.Error{
+			Package: "Bench",
+			Func:    "Validate",
+			Message: er.ErrInvalidHold,
+			Raw:     fmt.Errorf("hold %s, ping window %s", b.hold, b.pingWindow),
+		}
 	}
-	if b.keepAlive == 0 {
-		b.keepAlive = DefaultKeepAlive
+	if b.iterations <= 0 {
+		return &er.Error{
+			Package: "Bench",
+			Func:    "Validate",
+			Message: er.ErrInvalidIterations,
+			Raw:     er.ErrInvalidIterations,
+		}
 	}
-	if b.cleanSession == nil {
-		cs := true
-		b.cleanSession = &cs
+	return nil
+}
+
+// validatePacing checks the publish arrival, in-flight window and warm-up
+func (b *Bench) validatePacing() error {
+	if b.delay < 0 || b.interval < 0 {
+		return &er.Error{
+			Package: "Bench",
+			Func:    "Validate",
+			Message: er.ErrInvalidDelay,
+			Raw:     er.ErrInvalidDelay,
+		}
+	}
+	switch b.arrival {
+	case ArrivalConstant, ArrivalPoisson, ArrivalUniform, ArrivalBurst:
+	default:
+		return &er.Error{
+			Package: "Bench",
+			Func:    "Validate",
+			Message: er.ErrInvalidArrival,
+			Raw:     fmt.Errorf("unknown arrival %q", b.arrival),
+		}
+	}
+	// Jitter beyond the delay would need negative waits, which raise the mean once clamped
+	if b.jitter < 0 || (b.arrival == ArrivalUniform && time.Duration(b.jitter)*time.Millisecond > b.meanDelay()) {
+		return &er.Error{
+			Package: "Bench",
+			Func:    "Validate",
+			Message: er.ErrInvalidJitter,
+			Raw:     fmt.Errorf("jitter %dms with delay %s", b.jitter, b.meanDelay()),
+		}
+	}
+	if b.burstSize <= 0 {
+		return &er.Error{
+			Package: "Bench",
+			Func:    "Validate",
+			Message: er.ErrInvalidBurstSize,
+			Raw:     er.ErrInvalidBurstSize,
+		}
+	}
+	// Every in-flight QoS 1/2 message needs its own packet identifier
+	if b.maxInflight <= 0 || b.maxInflight > 65535 {
+		return &er.Error{
+			Package: "Bench",
+			Func:    "Validate",
+			Message: er.ErrInvalidMaxInflight,
+			Raw:     fmt.Errorf("max in-flight %d", b.maxInflight),
+		}
+	}
+	if b.publishTimeout <= 0 {
+		return &er.Error{
+			Package: "Bench",
+			Func:    "Validate",
+			Message: er.ErrInvalidPublishTimeout,
+			Raw:     fmt.Errorf("publish timeout %s", b.publishTimeout),
+		}
+	}
+	if b.warmup < 0 || b.warmupCount < 0 {
+		return &er.Error{
+			Package: "Bench",
+			Func:    "Validate",
+			Message: er.ErrInvalidWarmup,
+			Raw:     er.ErrInvalidWarmup,
+		}
 	}
 	return nil
 }
 
+// validatePayloads checks the payload sources and their options
+func (b *Bench) validatePayloads() error {
+	sources := 0
+	for _, set := range []bool{b.payloadFile != "", b.payloadDir != "", b.payloadSizeSpec != ""} {
+		if set {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return &er.Error{
+			Package: "Bench",
+			Func:    "Validate",
+			Message: er.ErrConflictingPayloads,
+			Raw:     er.ErrConflictingPayloads,
+		}
+	}
+	switch b.corpusOrder {
+	case OrderSequential, OrderRandom:
+	default:
+		return &er.Error{
+			Package: "Bench",
+			Func:    "Validate",
+			Message: er.ErrInvalidCorpusOrder,
+			Raw:     fmt.Errorf("unknown payload order %q", b.corpusOrder),
+		}
+	}
+	switch b.payloadContent {
+	case ContentRandom, ContentZeros, ContentText, ContentPrintable:
+	default:
+		return &er.Error{
+			Package: "Bench",
+			Func:    "Validate",
+			Message: er.ErrInvalidPayloadContent,
+			Raw:     fmt.Errorf("unknown payload content %q", b.payloadContent),
+		}
+	}
+	switch b.checksum {
+	case ChecksumNone, ChecksumCRC32, ChecksumXXHash:
+	default:
+		return &er.Error{
+			Package: "Bench",
+			Func:    "Validate",
+			Message: er.ErrInvalidChecksum,
+			Raw:     fmt.Errorf("unknown checksum %q", b.checksum),
+		}
+	}
+	return nil
+}
+
+// validateSubscriptions checks the subscriber, probe, retained and session settings
+func (b *Bench) validateSubscriptions() error {
+	if b.publishers < 0 {
+		return &er.Error{
+			Package: "Bench",
+			Func:    "Validate",
+			Message: er.ErrInvalidPublishers,
+			Raw:     fmt.Errorf("publishers %d", b.publishers),
+		}
+	}
+	if b.probeTopics < 0 || b.probeCount < 0 {
+		return &er.Error{
+			Package: "Bench",
+			Func:    "Validate",
+			Message: er.ErrInvalidProbe,
+			Raw:     er.ErrInvalidProbe,
+		}
+	}
+	if b.shareGroups < 0 || (b.shareGroups > 1 && b.shareGroup == "") || strings.ContainsAny(b.shareGroup, "/+#") {
+		return &er.Error{
+			Package: "Bench",
+			Func:    "Validate",
+			Message: er.ErrInvalidShareGroup,
+			Raw:     fmt.Errorf("share group %q, groups %d", b.shareGroup, b.shareGroups),
+		}
+	}
+	// Without a template every subscription renders the same filter
+	if b.subscriptions <= 0 || (b.subscriptions > 1 && !isTemplate(b.topic)) {
+		return &er.Error{
+			Package: "Bench",
+			Func:    "Validate",
+			Message: er.ErrInvalidSubscriptions,
+			Raw:     fmt.Errorf("subscriptions %d to topic %q", b.subscriptions, b.topic),
+		}
+	}
+	if b.retainedFilter == "" || b.retainedTimeout <= 0 {
+		return &er.Error{
+			Package: "Bench",
+			Func:    "Validate",
+			Message: er.ErrInvalidRetained,
+			Raw:     fmt.Errorf("filter %q, timeout %s", b.retainedFilter, b.retainedTimeout),
+		}
+	}
+	if b.sessionTimeout <= 0 {
+		return &er.Error{
+			Package: "Bench",
+			Func:    "Validate",
+			Message: er.ErrInvalidSessionTimeout,
+			Raw:     er.ErrInvalidSessionTimeout,
+		}
+	}
+	return nil
+}
+
+// validateWills checks the will and will monitor settings
+func (b *Bench) validateWills() error {
+	if b.willQoS > 2 || (b.willTopic == "" && (b.willPayload != "" || b.willRetain)) {
+		return &er.Error{
+			Package: "Bench",
+			Func:    "Validate",
+			Message: er.ErrInvalidWill,
+			Raw:     fmt.Errorf("will topic %q, qos %d", b.willTopic, b.willQoS),
+		}
+	}
+	if b.willFilter == "" || b.willMonitors <= 0 || b.willTimeout <= 0 {
+		return &er.Error{
+			Package: "Bench",
+			Func:    "Validate",
+			Message: er.ErrInvalidWillMonitors,
+			Raw:     fmt.Errorf("filter %q, monitors %d, timeout %s", b.willFilter, b.willMonitors, b.willTimeout),
+		}
+	}
+	return nil
+}
+
+// validateProcessing checks the slow subscriber settings
+func (b *Bench) validateProcessing() error {
+	if b.queueSize <= 0 || b.slowClients < 0 || b.slowClients > b.clients {
+		return &er.Error{
+			Package: "Bench",
+			Func:    "Validate",
+			Message: er.ErrInvalidProcessing,
+			Raw:     fmt.Errorf("queue size %d, slow clients %d of %d", b.queueSize, b.slowClients, b.clients),
+		}
+	}
+	return nil
+}
+
+// meanDelay returns the mean wait between messages, the interval when set and
+// the delay otherwise
+func (b *Bench) meanDelay() time.Duration {
+	if b.interval > 0 {
+		return b.interval
+	}
+	return time.Duration(b.delay) * time.Millisecond
+}
+
 func WithDelay(delay int) Option {
 	return func(b *Bench) {
 		b.delay = delay
 	}
 }
 
+func WithInterval(interval time.Duration) Option {
+	return func(b *Bench) {
+		b.interval = interval
+	}
+}
+
 func WithClients(clients int) Option {
 	return func(b *Bench) {
 		b.clients = clients
@@ -252,3 +664,206 @@ func WithPassword(password string) Option {
 		b.password = password
 	}
 }
+
+func WithConnectConcurrency(concurrency int) Option {
+	return func(b *Bench) {
+		b.connectConcurrency = concurrency
+	}
+}
+
+func WithConnectRate(rate float64) Option {
+	return func(b *Bench) {
+		b.connectRate = rate
+	}
+}
+
+func WithArrival(arrival Arrival) Option {
+	return func(b *Bench) {
+		b.arrival = arrival
+	}
+}
+
+func WithJitter(jitter int) Option {
+	return func(b *Bench) {
+		b.jitter = jitter
+	}
+}
+
+func WithBurstSize(size int) Option {
+	return func(b *Bench) {
+		b.burstSize = size
+	}
+}
+
+func WithHold(hold, pingWindow time.Duration) Option {
+	return func(b *Bench) {
+		b.hold = hold
+		b.pingWindow = pingWindow
+	}
+}
+
+func WithMaxInflight(n int) Option {
+	return func(b *Bench) {
+		b.maxInflight = n
+	}
+}
+
+func WithPublishTimeout(timeout time.Duration) Option {
+	return func(b *Bench) {
+		b.publishTimeout = timeout
+	}
+}
+
+func WithSeed(seed int64) Option {
+	return func(b *Bench) {
+		b.seed = seed
+	}
+}
+
+func WithWarmup(warmup time.Duration) Option {
+	return func(b *Bench) {
+		b.warmup = warmup
+	}
+}
+
+func WithWarmupCount(count int) Option {
+	return func(b *Bench) {
+		b.warmupCount = count
+	}
+}
+
+func WithIterations(iterations int) Option {
+	return func(b *Bench) {
+		b.iterations = iterations
+	}
+}
+
+func WithCooldown(cooldown time.Duration) Option {
+	return func(b *Bench) {
+		b.cooldown = cooldown
+	}
+}
+
+func WithPayloadSize(spec string) Option {
+	return func(b *Bench) {
+		b.payloadSizeSpec = spec
+	}
+}
+
+func WithPayloadContent(content PayloadContent) Option {
+	return func(b *Bench) {
+		b.payloadContent = content
+	}
+}
+
+func WithMessageFile(path string) Option {
+	return func(b *Bench) {
+		b.messageFile = path
+	}
+}
+
+func WithPayloadFile(path string, format CorpusFormat) Option {
+	return func(b *Bench) {
+		b.payloadFile = path
+		b.payloadFormat = format
+	}
+}
+
+func WithPayloadDir(dir string) Option {
+	return func(b *Bench) {
+		b.payloadDir = dir
+	}
+}
+
+func WithPayloadOrder(order CorpusOrder) Option {
+	return func(b *Bench) {
+		b.corpusOrder = order
+	}
+}
+
+func WithProbe(topic string, topics int, count int) Option {
+	return func(b *Bench) {
+		b.probeTopic = topic
+		b.probeTopics = topics
+		b.probeCount = count
+	}
+}
+
+func WithShareGroup(name string, groups int) Option {
+	return func(b *Bench) {
+		b.shareGroup = name
+		b.shareGroups = groups
+	}
+}
+
+func WithSubscriptions(perClient int, single bool) Option {
+	return func(b *Bench) {
+		b.subscriptions = perClient
+		b.singleSubscribe = single
+	}
+}
+
+func WithRetainedDelivery(filter string, timeout time.Duration, cleanup bool) Option {
+	return func(b *Bench) {
+		b.retainedFilter = filter
+		b.retainedTimeout = timeout
+		b.retainedCleanup = cleanup
+	}
+}
+
+func WithWill(topic string, payload string, qos byte, retain bool) Option {
+	return func(b *Bench) {
+		b.willTopic = topic
+		b.willPayload = payload
+		b.willQoS = qos
+		b.willRetain = retain
+	}
+}
+
+func WithWillMonitors(filter string, monitors int, timeout time.Duration) Option {
+	return func(b *Bench) {
+		b.willFilter = filter
+		b.willMonitors = monitors
+		b.willTimeout = timeout
+		b.willDelivery = true
+	}
+}
+
+func WithSequenceTag(tag bool) Option {
+	return func(b *Bench) {
+		b.sequenceTag = tag
+	}
+}
+
+func WithPublishers(publishers int) Option {
+	return func(b *Bench) {
+		b.publishers = publishers
+	}
+}
+
+func WithSessionTimeout(timeout time.Duration) Option {
+	return func(b *Bench) {
+		b.sessionTimeout = timeout
+	}
+}
+
+func WithChecksum(checksum Checksum) Option {
+	return func(b *Bench) {
+		b.checksum = checksum
+	}
+}
+
+func WithProcessing(processTime string, slowClients, queueSize int, drop bool) Option {
+	return func(b *Bench) {
+		b.processTimeSpec = processTime
+		b.slowClients = slowClients
+		b.queueSize = queueSize
+		b.queueDrop = drop
+	}
+}
+
+func WithSysTopics(enabled bool) Option {
+	return func(b *Bench) {
+		b.sysTopics = enabled
+	}
+}
@@ -0,0 +1,143 @@
+package bench
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"log/slog"
+	"sync/atomic"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/rayomqio/benchmq/pkg/logger"
+)
+
+// Checksum names the payload integrity checksum added by publishers
+type Checksum string
+
+const (
+	ChecksumNone   Checksum = ""       // No integrity header
+	ChecksumCRC32  Checksum = "crc32"  // CRC-32 (IEEE)
+	ChecksumXXHash Checksum = "xxhash" // 64-bit xxHash
+)
+
+// The integrity header is the magic, the algorithm, the body length as a
+// big-endian uint32 and the checksum as a big-endian uint64
+var checksumMagic = []byte("BMQ\x01")
+
+const checksumHeaderLen = 4 + 1 + 4 + 8
+
+// Algorithm identifiers in the header
+const (
+	algoCRC32  byte = 1
+	algoXXHash byte = 2
+)
+
+// Integrity holds the outcome of verifying checksummed payloads
+type Integrity struct {
+	Checked   int64 `json:"checked"`   // Payloads carrying an integrity header
+	Corrupted int64 `json:"corrupted"` // Payloads whose checksum didn't match
+	Truncated int64 `json:"truncated"` // Payloads shorter than the length in their header
+}
+
+// Attrs returns the integrity checks as log attributes
+func (i *Integrity) Attrs() []slog.Attr {
+	if i == nil {
+		return nil
+	}
+	return []slog.Attr{
+		logger.Any("integrityChecked", i.Checked),
+		logger.Any("integrityCorrupted", i.Corrupted),
+		logger.Any("integrityTruncated", i.Truncated),
+	}
+}
+
+// integrityCounter counts verified payloads across subscribers
+type integrityCounter struct {
+	expect    Checksum // Checksum every payload must carry, if any
+	checked   atomic.Int64
+	corrupted atomic.Int64
+	truncated atomic.Int64
+}
+
+// verify checks the integrity header of payload and returns the body after
+// the header. Without an expected checksum, payloads without a header are
+// returned unchanged. With one, a payload too short for the header counts as
+// truncated, and one whose header is damaged or uses another algorithm as
+// corrupted.
+func (c *integrityCounter) verify(payload []byte) []byte {
+	if len(payload) < checksumHeaderLen {
+		if c.expect != ChecksumNone {
+			c.checked.Add(1)
+			c.truncated.Add(1)
+		}
+		return payload
+	}
+	if !bytes.HasPrefix(payload, checksumMagic) {
+		if c.expect != ChecksumNone {
+			c.checked.Add(1)
+			c.corrupted.Add(1)
+		}
+		return payload
+	}
+	c.checked.Add(1)
+
+	algo := payload[4]
+	length := binary.BigEndian.Uint32(payload[5:9])
+	sum := binary.BigEndian.Uint64(payload[9:17])
+	body := payload[checksumHeaderLen:]
+
+	switch {
+	case c.expect != ChecksumNone && algo != c.expect.algo():
+		c.corrupted.Add(1)
+	case uint32(len(body)) < length:
+		c.truncated.Add(1)
+	case uint32(len(body)) > length || checksumOf(algo, body) != sum:
+		c.corrupted.Add(1)
+	}
+	return body
+}
+
+// summarize returns the counts, or nil when no payload carried a header
+func (c *integrityCounter) summarize() *Integrity {
+	if c.checked.Load() == 0 {
+		return nil
+	}
+	return &Integrity{
+		Checked:   c.checked.Load(),
+		Corrupted: c.corrupted.Load(),
+		Truncated: c.truncated.Load(),
+	}
+}
+
+// algo returns the header identifier of the checksum
+func (c Checksum) algo() byte {
+	if c == ChecksumXXHash {
+		return algoXXHash
+	}
+	return algoCRC32
+}
+
+// seal prepends the integrity header of payload. The header leads rather than
+// trails the payload, so a truncated payload still carries the length it lost.
+func (c Checksum) seal(payload []byte) []byte {
+	algo := c.algo()
+	out := make([]byte, checksumHeaderLen, checksumHeaderLen+len(payload))
+	copy(out, checksumMagic)
+	out[4] = algo
+	binary.BigEndian.PutUint32(out[5:9], uint32(len(payload)))
+	binary.BigEndian.PutUint64(out[9:17], checksumOf(algo, payload))
+	return append(out, payload...)
+}
+
+// checksumOf computes the checksum of data with the given algorithm
+func checksumOf(algo byte, data []byte) uint64 {
+	switch algo {
+	case algoCRC32:
+		return uint64(crc32.ChecksumIEEE(data))
+	case algoXXHash:
+		return xxhash.Sum64(data)
+	default:
+		// Unknown algorithms never match, so they count as corrupted
+		return ^uint64(0)
+	}
+}
@@ -0,0 +1,55 @@
+package bench
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestChecksumVerify(t *testing.T) {
+	body := []byte("payload under test")
+	crc := ChecksumCRC32.seal(body)
+	xx := ChecksumXXHash.seal(body)
+
+	flipped := bytes.Clone(crc)
+	flipped[len(flipped)-1] ^= 0xff
+	padded := append(bytes.Clone(crc), 'x')
+	unknown := bytes.Clone(crc)
+	unknown[4] = 9
+
+	tests := []struct {
+		name    string
+		expect  Checksum
+		payload []byte
+		body    []byte
+		want    Integrity
+	}{
+		{"crc32 round trip", ChecksumNone, crc, body, Integrity{Checked: 1}},
+		{"xxhash round trip", ChecksumNone, xx, body, Integrity{Checked: 1}},
+		{"expected round trip", ChecksumXXHash, xx, body, Integrity{Checked: 1}},
+		{"empty body", ChecksumCRC32, ChecksumCRC32.seal(nil), []byte{}, Integrity{Checked: 1}},
+		{"truncated body", ChecksumNone, crc[:len(crc)-3], body[:len(body)-3], Integrity{Checked: 1, Truncated: 1}},
+		{"flipped byte", ChecksumNone, flipped, flipped[checksumHeaderLen:], Integrity{Checked: 1, Corrupted: 1}},
+		{"extra byte", ChecksumNone, padded, padded[checksumHeaderLen:], Integrity{Checked: 1, Corrupted: 1}},
+		{"unknown algorithm", ChecksumNone, unknown, body, Integrity{Checked: 1, Corrupted: 1}},
+		{"other algorithm", ChecksumCRC32, xx, body, Integrity{Checked: 1, Corrupted: 1}},
+		{"unsealed", ChecksumNone, body, body, Integrity{}},
+		{"expected but unsealed", ChecksumCRC32, body, body, Integrity{Checked: 1, Corrupted: 1}},
+		{"short header", ChecksumNone, crc[:5], crc[:5], Integrity{}},
+		{"expected but short header", ChecksumCRC32, crc[:5], crc[:5], Integrity{Checked: 1, Truncated: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &integrityCounter{expect: tt.expect}
+			if got := c.verify(tt.payload); !bytes.Equal(got, tt.body) {
+				t.Errorf("verify body = %q, want %q", got, tt.body)
+			}
+			got := c.summarize()
+			if got == nil {
+				got = &Integrity{}
+			}
+			if *got != tt.want {
+				t.Errorf("verify counts = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
@@ -2,15 +2,34 @@ package bench
 
 import (
 	"fmt"
+	"log/slog"
+	"sync/atomic"
 	"time"
 
 	"github.com/rayomqio/benchmq/internal/mqtt"
 	"github.com/rayomqio/benchmq/pkg/logger"
 )
 
-func (b *Bench) RunConnections() {
+func (b *Bench) RunConnections() *Summary {
 	start := time.Now()
-	b.logger.Info("Started connection benchmark", logger.Int("time", int(start.UnixNano())))
+	b.logger.Info("Started connection benchmark",
+		logger.Int("time", int(start.UnixNano())),
+		logger.Int("connectConcurrency", b.connectConcurrency),
+		logger.Float("connectRate", b.connectRate),
+		logger.String("hold", b.hold.String()),
+	)
+
+	pool := b.newConnectPool()
+
+	var connected int64
+	var failed int64
+
+	// Held connections report every keepalive round trip
+	var pings *pingTracker
+	var timeouts, disconnects int64
+	if b.hold > 0 {
+		pings = newPingTracker(start, b.pingWindow)
+	}
 
 	for i := 0; i < b.clients; i++ {
 		b.wg.Add(1)
@@ -24,18 +43,61 @@ func (b *Bench) RunConnections() {
 			cfg.Client.Username = b.username
 			cfg.Client.Password = b.password
 			client := mqtt.NewClient(&cfg)
+			if pings != nil {
+				client.OnPing(pings.record)
+			}
 			defer client.Disconnect()
 
 			b.logger.Info("Connecting Client", logger.ClientID(cfg.Client.ClientID), logger.State("connecting"))
-			if err := client.Connect(); err != nil {
+			if err := pool.connect(client); err != nil {
+				atomic.AddInt64(&failed, 1)
 				b.logger.Error("Couldn't establish client", logger.ClientID(cfg.Client.ClientID), logger.State("failed"))
 				return
 			}
+			atomic.AddInt64(&connected, 1)
 			b.logger.LogClientConnection(cfg.Client.ClientID)
+
+			if pings != nil {
+				time.Sleep(b.hold)
+				atomic.AddInt64(&timeouts, client.KeepaliveTimeouts())
+				atomic.AddInt64(&disconnects, client.ConnectionsLost())
+			}
 		}(i)
-		time.Sleep(time.Duration(b.delay) * time.Millisecond)
+		// The fixed delay only spaces clients out when no pacing is configured
+		if !pool.paced() {
+			time.Sleep(b.meanDelay())
+		}
 	}
 
 	b.wg.Wait()
-	b.logger.Info("Finished connection benchmark", logger.Any("time", time.Since(start).Seconds()))
+
+	summary := &Summary{
+		Benchmark: "conn",
+		Clients:   b.clients,
+		Expected:  int64(b.clients),
+		Succeeded: connected,
+		Failed:    failed,
+		Elapsed:   time.Since(start),
+	}
+	if pings != nil {
+		summary.Pings = pings.summarize(timeouts, disconnects)
+	}
+
+	attrs := []slog.Attr{
+		logger.Any("time", summary.Elapsed.Seconds()),
+		logger.Any("connected", connected),
+		logger.Any("failed", failed),
+	}
+	attrs = append(attrs, summary.Pings.Attrs()...)
+	b.logger.Info("Finished connection benchmark", attrs...)
+
+	if summary.Pings != nil {
+		for _, w := range summary.Pings.Windows {
+			attrs := []slog.Attr{logger.Float("windowStartSec", w.Start.Seconds())}
+			attrs = append(attrs, w.Latency.AttrsNamed("ping")...)
+			b.logger.Info("Ping round trips", attrs...)
+		}
+	}
+
+	return summary
 }
@@ -0,0 +1,115 @@
+package bench
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/rayomqio/benchmq/pkg/er"
+)
+
+// CorpusFormat names how payloads are framed inside a payload file
+type CorpusFormat string
+
+const (
+	FormatLines          CorpusFormat = "lines"           // One payload per line
+	FormatLengthPrefixed CorpusFormat = "length-prefixed" // 4-byte big-endian length before each payload
+)
+
+// CorpusOrder names the order in which clients replay the corpus
+type CorpusOrder string
+
+const (
+	OrderSequential CorpusOrder = "sequential" // Cycle through the corpus in order
+	OrderRandom     CorpusOrder = "random"     // Pick a random payload per message
+)
+
+// loadCorpusFile reads every payload framed in the file at path
+func loadCorpusFile(path string, format CorpusFormat) ([][]byte, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, corpusError("loadCorpusFile", err)
+	}
+
+	var corpus [][]byte
+	switch format {
+	case FormatLines:
+		scanner := bufio.NewScanner(bytes.NewReader(raw))
+		scanner.Buffer(make([]byte, 0, 64*1024), len(raw)+1)
+		for scanner.Scan() {
+			line := bytes.TrimSuffix(scanner.Bytes(), []byte("\r"))
+			if len(line) > 0 {
+				corpus = append(corpus, bytes.Clone(line))
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, corpusError("loadCorpusFile", err)
+		}
+	case FormatLengthPrefixed:
+		r := bytes.NewReader(raw)
+		for {
+			var n uint32
+			if err := binary.Read(r, binary.BigEndian, &n); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, corpusError("loadCorpusFile", err)
+			}
+			// Check the prefix before allocating, a corrupt one could ask for 4 GiB
+			if int64(n) > int64(r.Len()) {
+				return nil, corpusError("loadCorpusFile", fmt.Errorf("payload %d needs %d bytes, %d left", len(corpus), n, r.Len()))
+			}
+			payload := make([]byte, n)
+			if _, err := io.ReadFull(r, payload); err != nil {
+				return nil, corpusError("loadCorpusFile", fmt.Errorf("truncated payload %d: %w", len(corpus), err))
+			}
+			corpus = append(corpus, payload)
+		}
+	default:
+		return nil, corpusError("loadCorpusFile", fmt.Errorf("unknown payload format %q", format))
+	}
+
+	if len(corpus) == 0 {
+		return nil, corpusError("loadCorpusFile", fmt.Errorf("%s contains no payloads", path))
+	}
+	return corpus, nil
+}
+
+// loadCorpusDir reads every regular file in dir verbatim as one payload, in name order
+func loadCorpusDir(dir string) ([][]byte, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, corpusError("loadCorpusDir", err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	var corpus [][]byte
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		payload, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, corpusError("loadCorpusDir", err)
+		}
+		corpus = append(corpus, payload)
+	}
+
+	if len(corpus) == 0 {
+		return nil, corpusError("loadCorpusDir", fmt.Errorf("%s contains no files", dir))
+	}
+	return corpus, nil
+}
+
+func corpusError(fn string, err error) error {
+	return &er.Error{
+		Package: "Bench",
+		Func:    fn,
+		Message: er.ErrCorpusLoadFailed,
+		Raw:     err,
+	}
+}
@@ -0,0 +1,97 @@
+package bench
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/rayomqio/benchmq/pkg/er"
+)
+
+func lengthPrefixed(payloads ...string) []byte {
+	var raw []byte
+	for _, p := range payloads {
+		raw = binary.BigEndian.AppendUint32(raw, uint32(len(p)))
+		raw = append(raw, p...)
+	}
+	return raw
+}
+
+func TestLoadCorpusFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  CorpusFormat
+		raw     []byte
+		want    []string
+		wantErr bool
+	}{
+		{"lines", FormatLines, []byte("a\nbb\n\nccc"), []string{"a", "bb", "ccc"}, false},
+		{"crlf lines", FormatLines, []byte("a\r\nbb\r\n"), []string{"a", "bb"}, false},
+		{"long line", FormatLines, append(make([]byte, 100_000), '\n'), []string{string(make([]byte, 100_000))}, false},
+		{"only blank lines", FormatLines, []byte("\n\r\n\n"), nil, true},
+		{"length prefixed", FormatLengthPrefixed, lengthPrefixed("x", "", "a\nb"), []string{"x", "", "a\nb"}, false},
+		{"oversized prefix", FormatLengthPrefixed, []byte{0xff, 0xff, 0xff, 0xff, 'x'}, nil, true},
+		{"partial prefix", FormatLengthPrefixed, append(lengthPrefixed("x"), 0, 0), nil, true},
+		{"empty file", FormatLengthPrefixed, nil, nil, true},
+		{"unknown format", CorpusFormat("csv"), []byte("a"), nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "corpus")
+			if err := os.WriteFile(path, tt.raw, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			corpus, err := loadCorpusFile(path, tt.format)
+			if tt.wantErr {
+				if !errors.Is(err, er.ErrCorpusLoadFailed) {
+					t.Fatalf("error = %v, want ErrCorpusLoadFailed", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := corpusStrings(corpus); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("corpus = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadCorpusDir(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{"b.json": "2", "a.json": "1", "c.bin": ""} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "nested"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	corpus, err := loadCorpusDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := corpusStrings(corpus), []string{"1", "2", ""}; !reflect.DeepEqual(got, want) {
+		t.Errorf("corpus = %q, want %q in name order", got, want)
+	}
+
+	if _, err := loadCorpusDir(t.TempDir()); !errors.Is(err, er.ErrCorpusLoadFailed) {
+		t.Errorf("empty dir error = %v, want ErrCorpusLoadFailed", err)
+	}
+	if _, err := loadCorpusDir(filepath.Join(dir, "missing")); !errors.Is(err, er.ErrCorpusLoadFailed) {
+		t.Errorf("missing dir error = %v, want ErrCorpusLoadFailed", err)
+	}
+}
+
+func corpusStrings(corpus [][]byte) []string {
+	var out []string
+	for _, p := range corpus {
+		out = append(out, string(p))
+	}
+	return out
+}
@@ -0,0 +1,34 @@
+package bench
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/rayomqio/benchmq/pkg/er"
+	"github.com/rayomqio/benchmq/pkg/redact"
+)
+
+// Export writes the report as indented JSON to path
+func Export(path string, report any) error {
+	raw, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return &er.Error{
+			Package: "Bench",
+			Func:    "Export",
+			Message: er.ErrExportFailed,
+			Raw:     err,
+		}
+	}
+
+	raw = redact.JSON(raw)
+	if err := os.WriteFile(path, append(raw, '\n'), 0o644); err != nil {
+		return &er.Error{
+			Package: "Bench",
+			Func:    "Export",
+			Message: er.ErrExportFailed,
+			Raw:     err,
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package bench
+
+import (
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/rayomqio/benchmq/pkg/logger"
+)
+
+// Estimate holds the sample statistics of a metric across iterations
+type Estimate struct {
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stddev"`
+	CILow  float64 `json:"ci95Low"`
+	CIHigh float64 `json:"ci95High"`
+}
+
+// Iterations holds the aggregate and raw results of a repeated benchmark
+type Iterations struct {
+	Count        int        `json:"count"`
+	Cooldown     string     `json:"cooldown"`
+	Throughput   Estimate   `json:"throughputPerSec"`
+	BytesRate    Estimate   `json:"throughputBytesPerSec"`
+	LatencyP50Ms *Estimate  `json:"latencyP50Ms,omitempty"`
+	LatencyP90Ms *Estimate  `json:"latencyP90Ms,omitempty"`
+	LatencyP99Ms *Estimate  `json:"latencyP99Ms,omitempty"`
+	Runs         []*Summary `json:"runs"`
+}
+
+// tCritical95 holds two-sided 95% Student's t critical values by degrees of freedom
+var tCritical95 = []float64{
+	0, 12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+// Repeat runs the benchmark for the configured iterations, cooling down between
+// runs, and reports mean, standard deviation and 95% confidence intervals
+func (b *Bench) Repeat(run func() *Summary) *Iterations {
+	count, cooldown := b.iterations, b.cooldown
+	it := &Iterations{
+		Count:    count,
+		Cooldown: cooldown.String(),
+		Runs:     make([]*Summary, 0, count),
+	}
+
+	for i := 0; i < count; i++ {
+		if i > 0 && cooldown > 0 {
+			b.logger.Info("Cooling down", logger.Int("iteration", i+1), logger.Any("cooldown", cooldown.String()))
+			time.Sleep(cooldown)
+		}
+		if count > 1 {
+			b.logger.Info("Started iteration", logger.Int("iteration", i+1), logger.Int("iterations", count))
+		}
+		it.Runs = append(it.Runs, b.monitored(run))
+	}
+
+	it.Throughput = estimate(it.Runs, func(s *Summary) (float64, bool) { return s.Throughput(), true })
+	it.BytesRate = estimate(it.Runs, func(s *Summary) (float64, bool) { return s.BytesThroughput(), true })
+	it.LatencyP50Ms = latencyEstimate(it.Runs, func(l *Latency) time.Duration { return l.P50 })
+	it.LatencyP90Ms = latencyEstimate(it.Runs, func(l *Latency) time.Duration { return l.P90 })
+	it.LatencyP99Ms = latencyEstimate(it.Runs, func(l *Latency) time.Duration { return l.P99 })
+
+	if count > 1 {
+		b.logger.Info("Finished iterations", it.Attrs()...)
+	}
+
+	return it
+}
+
+// monitored runs the benchmark, recording the broker's $SYS statistics
+// alongside it when requested
+func (b *Bench) monitored(run func() *Summary) *Summary {
+	if !b.sysTopics {
+		return run()
+	}
+
+	monitor := b.startSysMonitor()
+	summary := run()
+	if monitor == nil {
+		return summary
+	}
+	summary.Sys = monitor.stop()
+
+	b.logger.Info("Broker $SYS statistics", summary.Sys.Attrs()...)
+	for _, t := range summary.Sys.Topics {
+		if t.Numeric {
+			b.logger.Info("Broker statistic", t.Attrs()...)
+		}
+	}
+	return summary
+}
+
+// Attrs returns the aggregate statistics as log attributes
+func (it *Iterations) Attrs() []slog.Attr {
+	attrs := []slog.Attr{logger.Int("iterations", it.Count)}
+	attrs = append(attrs, it.Throughput.attrs("throughputPerSec")...)
+	attrs = append(attrs, it.BytesRate.attrs("throughputBytesPerSec")...)
+	if it.LatencyP50Ms != nil {
+		attrs = append(attrs, it.LatencyP50Ms.attrs("latencyP50Ms")...)
+	}
+	if it.LatencyP90Ms != nil {
+		attrs = append(attrs, it.LatencyP90Ms.attrs("latencyP90Ms")...)
+	}
+	if it.LatencyP99Ms != nil {
+		attrs = append(attrs, it.LatencyP99Ms.attrs("latencyP99Ms")...)
+	}
+	return attrs
+}
+
+func (e Estimate) attrs(name string) []slog.Attr {
+	return []slog.Attr{
+		logger.Float(name+"Mean", e.Mean),
+		logger.Float(name+"StdDev", e.StdDev),
+		logger.Float(name+"CI95Low", e.CILow),
+		logger.Float(name+"CI95High", e.CIHigh),
+	}
+}
+
+// latencyEstimate estimates a latency percentile over the runs that recorded latencies
+func latencyEstimate(runs []*Summary, pick func(*Latency) time.Duration) *Estimate {
+	e := estimate(runs, func(s *Summary) (float64, bool) {
+		if s.Latency == nil {
+			return 0, false
+		}
+		return ms(pick(s.Latency)), true
+	})
+	if math.IsNaN(e.Mean) {
+		return nil
+	}
+	return &e
+}
+
+// estimate computes the sample mean, standard deviation and 95% confidence
+// interval of the metric. Mean is NaN when no run provided a value.
+func estimate(runs []*Summary, metric func(*Summary) (float64, bool)) Estimate {
+	values := make([]float64, 0, len(runs))
+	for _, r := range runs {
+		if v, ok := metric(r); ok {
+			values = append(values, v)
+		}
+	}
+
+	n := len(values)
+	if n == 0 {
+		return Estimate{Mean: math.NaN()}
+	}
+
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(n)
+	if n == 1 {
+		return Estimate{Mean: mean, CILow: mean, CIHigh: mean}
+	}
+
+	var sq float64
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	stddev := math.Sqrt(sq / float64(n-1))
+
+	t := 1.960
+	if df := n - 1; df < len(tCritical95) {
+		t = tCritical95[df]
+	}
+	margin := t * stddev / math.Sqrt(float64(n))
+
+	return Estimate{
+		Mean:   mean,
+		StdDev: stddev,
+		CILow:  mean - margin,
+		CIHigh: mean + margin,
+	}
+}
@@ -0,0 +1,70 @@
+package bench
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		want   Estimate
+	}{
+		{"single run", []float64{5}, Estimate{Mean: 5, CILow: 5, CIHigh: 5}},
+		{"identical runs", []float64{4, 4, 4}, Estimate{Mean: 4, CILow: 4, CIHigh: 4}},
+		{"t table", []float64{1, 2, 3}, Estimate{Mean: 2, StdDev: 1, CILow: 2 - 4.303/math.Sqrt(3), CIHigh: 2 + 4.303/math.Sqrt(3)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runs := make([]*Summary, len(tt.values))
+			for i, v := range tt.values {
+				runs[i] = &Summary{Succeeded: int64(v)}
+			}
+			got := estimate(runs, func(s *Summary) (float64, bool) {
+				return float64(s.Succeeded), true
+			})
+			if !near(got.Mean, tt.want.Mean) || !near(got.StdDev, tt.want.StdDev) ||
+				!near(got.CILow, tt.want.CILow) || !near(got.CIHigh, tt.want.CIHigh) {
+				t.Errorf("estimate(%v) = %+v, want %+v", tt.values, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEstimateNormalFallback(t *testing.T) {
+	runs := make([]*Summary, 50)
+	for i := range runs {
+		runs[i] = &Summary{Succeeded: int64(i % 2)}
+	}
+	got := estimate(runs, func(s *Summary) (float64, bool) {
+		return float64(s.Succeeded), true
+	})
+	margin := 1.960 * got.StdDev / math.Sqrt(50)
+	if !near(got.CIHigh-got.Mean, margin) {
+		t.Errorf("margin %v, want the normal approximation %v", got.CIHigh-got.Mean, margin)
+	}
+}
+
+func TestLatencyEstimate(t *testing.T) {
+	p50 := func(l *Latency) time.Duration { return l.P50 }
+
+	if got := latencyEstimate([]*Summary{{}, {}}, p50); got != nil {
+		t.Errorf("runs without latencies: got %+v, want nil", got)
+	}
+
+	runs := []*Summary{
+		{Latency: &Latency{P50: 2 * time.Millisecond}},
+		{},
+		{Latency: &Latency{P50: 4 * time.Millisecond}},
+	}
+	got := latencyEstimate(runs, p50)
+	if got == nil || !near(got.Mean, 3) {
+		t.Errorf("latencyEstimate = %+v, want a mean of 3ms over the runs with latencies", got)
+	}
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
@@ -0,0 +1,266 @@
+package bench
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/rayomqio/benchmq/pkg/er"
+)
+
+// PayloadContent names how generated payload bytes are filled
+type PayloadContent string
+
+const (
+	ContentRandom    PayloadContent = "random"    // Uniformly random bytes
+	ContentZeros     PayloadContent = "zeros"     // All zero bytes
+	ContentText      PayloadContent = "text"      // Highly compressible repeated text
+	ContentPrintable PayloadContent = "printable" // Random printable ASCII
+)
+
+const (
+	printable = " !\"#$%&'()*+,-./0123456789:;<=>?@ABCDEFGHIJKLMNOPQRSTUVWXYZ[\\]^_`abcdefghijklmnopqrstuvwxyz{|}~"
+	loremText = "Lorem ipsum dolor sit amet, consectetur adipiscing elit, sed do eiusmod tempor incididunt ut labore et dolore magna aliqua. "
+)
+
+// MaxPayloadSize is the largest payload an MQTT PUBLISH can carry
+const MaxPayloadSize = 1<<28 - 1
+
+// maxWeights bounds the sum of the weights of a weighted size list
+const maxWeights = 1 << 31
+
+// PayloadSize describes the distribution of generated payload sizes
+type PayloadSize struct {
+	spec    string
+	sizes   []int // Fixed size, inclusive range bounds, or weighted choices
+	weights []int // Set for weighted lists only
+	total   int   // Sum of weights
+	ranged  bool
+}
+
+// ParsePayloadSize parses a size distribution: a fixed size ("1024", "64KB"),
+// an inclusive uniform range ("512-4096") or a weighted list ("1KB:3,64KB:1")
+func ParsePayloadSize(spec string) (*PayloadSize, error) {
+	ps := &PayloadSize{spec: spec}
+
+	switch {
+	case strings.Contains(spec, ":"):
+		for _, part := range strings.Split(spec, ",") {
+			sizeStr, weightStr, ok := strings.Cut(part, ":")
+			if !ok {
+				return nil, invalidPayloadSize(spec)
+			}
+			size, err := parseSize(sizeStr)
+			if err != nil {
+				return nil, invalidPayloadSize(spec)
+			}
+			weight, err := strconv.Atoi(strings.TrimSpace(weightStr))
+			if err != nil || weight <= 0 || weight > maxWeights-ps.total {
+				return nil, invalidPayloadSize(spec)
+			}
+			ps.sizes = append(ps.sizes, size)
+			ps.weights = append(ps.weights, weight)
+			ps.total += weight
+		}
+	case strings.Contains(spec, "-"):
+		lo, hi, _ := strings.Cut(spec, "-")
+		minSize, err := parseSize(lo)
+		if err != nil {
+			return nil, invalidPayloadSize(spec)
+		}
+		maxSize, err := parseSize(hi)
+		if err != nil || maxSize < minSize {
+			return nil, invalidPayloadSize(spec)
+		}
+		ps.sizes = []int{minSize, maxSize}
+		ps.ranged = true
+	default:
+		size, err := parseSize(spec)
+		if err != nil {
+			return nil, invalidPayloadSize(spec)
+		}
+		ps.sizes = []int{size}
+	}
+
+	return ps, nil
+}
+
+// Max returns the largest size the distribution can produce
+func (ps *PayloadSize) Max() int {
+	m := 0
+	for _, size := range ps.sizes {
+		m = max(m, size)
+	}
+	return m
+}
+
+// String returns the original specification
+func (ps *PayloadSize) String() string {
+	return ps.spec
+}
+
+// pick draws a size from the distribution
+func (ps *PayloadSize) pick(rng *rand.Rand) int {
+	switch {
+	case ps.ranged:
+		return ps.sizes[0] + rng.IntN(ps.sizes[1]-ps.sizes[0]+1)
+	case ps.weights != nil:
+		n := rng.IntN(ps.total)
+		for i, w := range ps.weights {
+			if n < w {
+				return ps.sizes[i]
+			}
+			n -= w
+		}
+		return ps.sizes[len(ps.sizes)-1]
+	default:
+		return ps.sizes[0]
+	}
+}
+
+// parseSize parses a byte count with an optional K/KB/M/MB suffix (powers of
+// 1024), up to MaxPayloadSize
+func parseSize(s string) (int, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	mult := 1
+	for _, unit := range []struct {
+		suffix string
+		mult   int
+	}{{"MB", 1 << 20}, {"M", 1 << 20}, {"KB", 1 << 10}, {"K", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSuffix(s, unit.suffix)
+			mult = unit.mult
+			break
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	// Checked before multiplying, which could overflow
+	if n > MaxPayloadSize/mult {
+		return 0, fmt.Errorf("size %q exceeds %d bytes", s, MaxPayloadSize)
+	}
+	return n * mult, nil
+}
+
+func invalidPayloadSize(spec string) error {
+	return &er.Error{
+		Package: "Bench",
+		Func:    "ParsePayloadSize",
+		Message: er.ErrInvalidPayloadSize,
+		Raw:     fmt.Errorf("invalid payload size %q", spec),
+	}
+}
+
+// payloads holds the payload settings and buffers shared by every client of a run
+type payloads struct {
+	corpus [][]byte           // Replayed payloads
+	order  CorpusOrder        // Corpus replay order
+	static []byte             // Used when no generator is configured
+	tmpl   *template.Template // Pre-parsed message template
+	size   *PayloadSize       // Generated payload sizes
+	buf    []byte             // Pre-filled content that payloads are sliced from
+}
+
+// newPayloads prepares the payload buffers of a run once, so generating a
+// message is a slice operation rather than a fill
+func (b *Bench) newPayloads() *payloads {
+	if b.corpus != nil {
+		return &payloads{corpus: b.corpus, order: b.corpusOrder}
+	}
+	if b.payloadSize == nil {
+		return &payloads{static: []byte(b.message), tmpl: b.messageTemplate}
+	}
+
+	// Slack beyond the largest size lets random offsets vary the content between
+	// messages; a megabyte of it is plenty, and keeps huge sizes from doubling
+	largest := b.payloadSize.Max()
+	n := largest + min(largest, 1<<20)
+	buf := make([]byte, n)
+	rng := newRand(b.seed, streamBuffer, 0)
+
+	switch b.payloadContent {
+	case ContentRandom:
+		for i := range buf {
+			buf[i] = byte(rng.UintN(256))
+		}
+	case ContentPrintable:
+		for i := range buf {
+			buf[i] = printable[rng.IntN(len(printable))]
+		}
+	case ContentText:
+		for i := range buf {
+			buf[i] = loremText[i%len(loremText)]
+		}
+	case ContentZeros:
+	}
+
+	return &payloads{size: b.payloadSize, buf: buf}
+}
+
+// payloadStream yields the payloads of a single client
+type payloadStream struct {
+	*payloads
+	cursor   int // Next corpus entry for sequential replay
+	rng      *rand.Rand
+	renderer *renderer
+}
+
+// stream creates the payload stream of the client with the given index
+func (p *payloads) stream(seed int64, index int, clientID string) *payloadStream {
+	s := &payloadStream{
+		payloads: p,
+		rng:      newRand(seed, streamPayloads, index),
+		// Clients start at different entries so they don't replay in lockstep
+		cursor: index,
+	}
+	if p.tmpl != nil {
+		s.renderer = newRenderer(p.tmpl, s.rng, index, clientID)
+	}
+	return s
+}
+
+// next returns the payload of the message with the given sequence.
+// The returned slice must not be modified.
+func (s *payloadStream) next(seq int) ([]byte, error) {
+	switch {
+	case s.corpus != nil:
+		if s.order == OrderRandom {
+			return s.corpus[s.rng.IntN(len(s.corpus))], nil
+		}
+		payload := s.corpus[s.cursor%len(s.corpus)]
+		s.cursor++
+		return payload, nil
+	case s.size != nil:
+		n := s.size.pick(s.rng)
+		offset := s.rng.IntN(len(s.buf) - n + 1)
+		return s.buf[offset : offset+n : offset+n], nil
+	case s.renderer != nil:
+		payload, err := s.renderer.render(seq)
+		if err != nil {
+			return nil, err
+		}
+		// The client may still hold the previous payload, so hand out a copy
+		return bytes.Clone(payload), nil
+	default:
+		return s.static, nil
+	}
+}
+
+// describePayload renders the payload settings for the report
+func (b *Bench) describePayload() string {
+	if b.corpus != nil {
+		return fmt.Sprintf("corpus(payloads=%d, order=%s)", len(b.corpus), b.corpusOrder)
+	}
+	if b.payloadSize == nil {
+		if b.messageTemplate != nil {
+			return "template"
+		}
+		return fmt.Sprintf("message(size=%d)", len(b.message))
+	}
+	return fmt.Sprintf("%s(size=%s)", b.payloadContent, b.payloadSize)
+}
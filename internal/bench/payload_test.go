@@ -0,0 +1,92 @@
+package bench
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int
+		wantErr bool
+	}{
+		{"0", 0, false},
+		{"1024", 1024, false},
+		{"64b", 64, false},
+		{"1K", 1 << 10, false},
+		{" 64KB ", 64 << 10, false},
+		{"2m", 2 << 20, false},
+		{"255MB", 255 << 20, false},
+		{"268435455", MaxPayloadSize, false},
+		{"268435456", 0, true},
+		{"256MB", 0, true},
+		{"9223372036854775807KB", 0, true},
+		{"-1", 0, true},
+		{"", 0, true},
+		{"1GB", 0, true},
+		{"abc", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseSize(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSize(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseSize(%q) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePayloadSize(t *testing.T) {
+	tests := []struct {
+		spec    string
+		max     int
+		wantErr bool
+	}{
+		{"1KB", 1 << 10, false},
+		{"512-4096", 4096, false},
+		{"1KB:3,64KB:1", 64 << 10, false},
+		{"4096-512", 0, true},
+		{"512-", 0, true},
+		{"1KB:0", 0, true},
+		{"1KB:x", 0, true},
+		{"1KB:3,64KB", 0, true},
+		{"1:2147483647,2:2", 0, true},
+		{"1KB-256MB", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			ps, err := ParsePayloadSize(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePayloadSize(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if ps.Max() != tt.max {
+				t.Errorf("Max() = %d, want %d", ps.Max(), tt.max)
+			}
+			if ps.String() != tt.spec {
+				t.Errorf("String() = %q, want %q", ps.String(), tt.spec)
+			}
+		})
+	}
+}
+
+func TestPayloadSizePick(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	ranged, _ := ParsePayloadSize("10-12")
+	weighted, _ := ParsePayloadSize("1:1,2:3")
+	for range 1000 {
+		if n := ranged.pick(rng); n < 10 || n > 12 {
+			t.Fatalf("ranged pick %d outside 10-12", n)
+		}
+		if n := weighted.pick(rng); n != 1 && n != 2 {
+			t.Fatalf("weighted pick %d not in the list", n)
+		}
+	}
+}
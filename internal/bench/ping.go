@@ -0,0 +1,93 @@
+package bench
+
+import (
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rayomqio/benchmq/pkg/logger"
+)
+
+// Pings holds the keepalive round trips measured while connections were held
+type Pings struct {
+	Samples           int           `json:"samples"`           // PINGREQs answered by the broker
+	KeepaliveTimeouts int64         `json:"keepaliveTimeouts"` // Disconnects because a PINGRESP didn't arrive in time
+	Disconnects       int64         `json:"disconnects"`       // Connections lost for any reason
+	Latency           *Latency      `json:"latency,omitempty"`
+	Windows           []*PingWindow `json:"windows,omitempty"`
+}
+
+// PingWindow holds the round trips answered within one window of the run
+type PingWindow struct {
+	Start   time.Duration `json:"start"` // Offset from the start of the run
+	Latency *Latency      `json:"latency"`
+}
+
+// Attrs returns the keepalive results as log attributes
+func (p *Pings) Attrs() []slog.Attr {
+	if p == nil {
+		return nil
+	}
+	attrs := []slog.Attr{
+		logger.Int("pingSamples", p.Samples),
+		logger.Any("keepaliveTimeouts", p.KeepaliveTimeouts),
+		logger.Any("disconnects", p.Disconnects),
+	}
+	return append(attrs, p.Latency.AttrsNamed("ping")...)
+}
+
+// pingTracker collects the ping round trips of all clients in fixed windows
+type pingTracker struct {
+	start   time.Time
+	window  time.Duration
+	all     latencies
+	mu      sync.Mutex
+	windows map[int]*latencies
+}
+
+func newPingTracker(start time.Time, window time.Duration) *pingTracker {
+	return &pingTracker{start: start, window: window, windows: make(map[int]*latencies)}
+}
+
+// record adds a round trip to the overall distribution and to the current window
+func (t *pingTracker) record(rtt time.Duration) {
+	t.all.record(rtt)
+
+	index := int(time.Since(t.start) / t.window)
+	t.mu.Lock()
+	w := t.windows[index]
+	if w == nil {
+		w = &latencies{}
+		t.windows[index] = w
+	}
+	t.mu.Unlock()
+	w.record(rtt)
+}
+
+// summarize returns the round trips with the disconnect counts, windows in order
+func (t *pingTracker) summarize(timeouts, disconnects int64) *Pings {
+	p := &Pings{
+		KeepaliveTimeouts: timeouts,
+		Disconnects:       disconnects,
+		Latency:           t.all.summarize(),
+	}
+	if p.Latency != nil {
+		p.Samples = p.Latency.Samples
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	indexes := make([]int, 0, len(t.windows))
+	for i := range t.windows {
+		indexes = append(indexes, i)
+	}
+	slices.Sort(indexes)
+	for _, i := range indexes {
+		p.Windows = append(p.Windows, &PingWindow{
+			Start:   time.Duration(i) * t.window,
+			Latency: t.windows[i].summarize(),
+		})
+	}
+	return p
+}
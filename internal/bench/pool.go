@@ -0,0 +1,48 @@
+package bench
+
+import (
+	"context"
+
+	"github.com/rayomqio/benchmq/internal/mqtt"
+	"golang.org/x/time/rate"
+)
+
+// connectPool paces CONNECT packets for a single benchmark run. At most
+// cap(slots) connection attempts are in flight at once, and when a limiter
+// is set new attempts are admitted at the configured rate.
+type connectPool struct {
+	slots   chan struct{}
+	limiter *rate.Limiter
+}
+
+// newConnectPool builds the pool from the connect concurrency and rate options.
+// A zero value for either option leaves that dimension unbounded.
+func (b *Bench) newConnectPool() *connectPool {
+	pool := &connectPool{}
+	if b.connectConcurrency > 0 {
+		pool.slots = make(chan struct{}, b.connectConcurrency)
+	}
+	if b.connectRate > 0 {
+		pool.limiter = rate.NewLimiter(rate.Limit(b.connectRate), 1)
+	}
+	return pool
+}
+
+// paced reports whether the pool throttles connection establishment at all
+func (p *connectPool) paced() bool {
+	return p.slots != nil || p.limiter != nil
+}
+
+// connect waits for a rate token and a free slot, then connects the client
+func (p *connectPool) connect(client *mqtt.Adapter) error {
+	if p.limiter != nil {
+		if err := p.limiter.Wait(context.Background()); err != nil {
+			return err
+		}
+	}
+	if p.slots != nil {
+		p.slots <- struct{}{}
+		defer func() { <-p.slots }()
+	}
+	return client.Connect()
+}
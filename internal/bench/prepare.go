@@ -0,0 +1,125 @@
+package bench
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rayomqio/benchmq/pkg/er"
+)
+
+// prepare loads and parses what the validated settings refer to: the message
+// file, payload corpus and sizes, templates and processing times. It then
+// picks the seed and renders the wills that depend on it.
+func (b *Bench) prepare() error {
+	for _, step := range []func() error{
+		b.loadMessage,
+		b.loadPayloads,
+		b.parseTemplates,
+		b.parseProcessTime,
+	} {
+		if err := step(); err != nil {
+			return err
+		}
+	}
+	// Pick a seed so the run can be reproduced from the report
+	if b.seed == 0 {
+		b.seed = time.Now().UnixNano()
+	}
+	// Rendered after the seed, since will templates may draw random values
+	if b.willDelivery && b.willTopic != "" {
+		return b.renderWills()
+	}
+	return nil
+}
+
+// loadMessage reads the message file and parses a templated message
+func (b *Bench) loadMessage() error {
+	if b.messageFile != "" {
+		raw, err := os.ReadFile(b.messageFile)
+		if err != nil {
+			return &er.Error{
+				Package: "Bench",
+				Func:    "Prepare",
+				Message: er.ErrMessageFileRead,
+				Raw:     err,
+			}
+		}
+		b.message = string(raw)
+	}
+	if !isTemplate(b.message) {
+		return nil
+	}
+	// Generated and replayed payloads replace the message, template included
+	if b.payloadFile != "" || b.payloadDir != "" || b.payloadSizeSpec != "" {
+		return &er.Error{
+			Package: "Bench",
+			Func:    "Prepare",
+			Message: er.ErrConflictingPayloads,
+			Raw:     fmt.Errorf("message template %q with a payload file, dir or size", b.message),
+		}
+	}
+	tmpl, err := parseTemplate("message", b.message)
+	if err != nil {
+		return err
+	}
+	b.messageTemplate = tmpl
+	return nil
+}
+
+// loadPayloads loads the payload corpus and parses the generated payload sizes
+func (b *Bench) loadPayloads() error {
+	var err error
+	switch {
+	case b.payloadFile != "":
+		b.corpus, err = loadCorpusFile(b.payloadFile, b.payloadFormat)
+	case b.payloadDir != "":
+		b.corpus, err = loadCorpusDir(b.payloadDir)
+	case b.payloadSizeSpec != "":
+		b.payloadSize, err = ParsePayloadSize(b.payloadSizeSpec)
+	}
+	return err
+}
+
+// parseTemplates parses the topic, probe and will templates
+func (b *Bench) parseTemplates() error {
+	var err error
+	if isTemplate(b.topic) {
+		if b.topicTemplate, err = parseTemplate("topic", b.topic); err != nil {
+			return err
+		}
+	}
+	if b.probeCount > 0 {
+		source := b.probeTopic
+		if source == "" {
+			source = b.topic
+		}
+		if b.probeTemplate, err = parseTemplate("probe", source); err != nil {
+			return err
+		}
+	}
+	if isTemplate(b.willTopic) {
+		if b.willTopicTemplate, err = parseTemplate("will-topic", b.willTopic); err != nil {
+			return err
+		}
+	}
+	if isTemplate(b.willPayload) {
+		if b.willPayloadTemplate, err = parseTemplate("will-payload", b.willPayload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseProcessTime parses the processing time of slow subscribers
+func (b *Bench) parseProcessTime() error {
+	if b.processTimeSpec == "" {
+		return nil
+	}
+	pt, err := ParseProcessTime(b.processTimeSpec)
+	if err != nil {
+		return err
+	}
+	b.processTime = pt
+	return nil
+}
@@ -0,0 +1,224 @@
+package bench
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rayomqio/benchmq/internal/mqtt"
+	"github.com/rayomqio/benchmq/pkg/er"
+	"github.com/rayomqio/benchmq/pkg/logger"
+)
+
+// probePrefix marks payloads published by the probe publisher
+var probePrefix = []byte("benchmq-probe|")
+
+// Verification holds the outcome of checking probe deliveries against the
+// subscription filters
+type Verification struct {
+	Topics     int   `json:"topics"`     // Distinct probe topics published to
+	Published  int64 `json:"published"`  // Probe messages published
+	Expected   int64 `json:"expected"`   // Deliveries expected across all subscribers
+	Delivered  int64 `json:"delivered"`  // Distinct expected deliveries received
+	Missing    int64 `json:"missing"`    // Expected deliveries never received
+	Duplicates int64 `json:"duplicates"` // Repeated deliveries of the same message
+	Mismatched int64 `json:"mismatched"` // Deliveries whose topic doesn't match the filter
+}
+
+// Attrs returns the verification as log attributes
+func (v *Verification) Attrs() []slog.Attr {
+	if v == nil {
+		return nil
+	}
+	return []slog.Attr{
+		logger.Int("probeTopics", v.Topics),
+		logger.Any("probePublished", v.Published),
+		logger.Any("probeExpected", v.Expected),
+		logger.Any("probeDelivered", v.Delivered),
+		logger.Any("probeMissing", v.Missing),
+		logger.Any("probeDuplicates", v.Duplicates),
+		logger.Any("probeMismatched", v.Mismatched),
+	}
+}
+
+// probe publishes a known set of messages once every subscriber is ready
+type probe struct {
+	topics []string // Rendered probe topics, indexed by topic number
+	count  int      // Messages per topic
+}
+
+// newProbe renders the probe topic template for every probe topic number
+func (b *Bench) newProbe() (*probe, error) {
+	p := &probe{count: b.probeCount, topics: make([]string, b.probeTopics)}
+
+	rng := newRand(b.seed, streamProbe, 0)
+	for i := range p.topics {
+		topic, err := newRenderer(b.probeTemplate, rng, i, b.clientID+"-probe").render(0)
+		if err != nil {
+			return nil, &er.Error{
+				Package: "Bench",
+				Func:    "newProbe",
+				Message: er.ErrInvalidTemplate,
+				Raw:     err,
+			}
+		}
+		if bytes.ContainsAny(topic, "+#") {
+			return nil, &er.Error{
+				Package: "Bench",
+				Func:    "newProbe",
+				Message: er.ErrInvalidProbeTopic,
+				Raw:     fmt.Errorf("probe topic %q", topic),
+			}
+		}
+		p.topics[i] = string(topic)
+	}
+
+	return p, nil
+}
+
+// publish sends count messages to every probe topic from a dedicated client
+func (p *probe) publish(b *Bench) (int64, error) {
+	cfg := *b.cfg
+	cfg.Client.ClientID = b.clientID + "-probe"
+	cfg.Client.CleanSession = true
+	cfg.Client.KeepAlive = b.keepAlive
+	cfg.Client.Username = b.username
+	cfg.Client.Password = b.password
+	client := mqtt.NewClient(&cfg)
+
+	if err := client.Connect(); err != nil {
+		return 0, err
+	}
+	defer client.Disconnect()
+
+	var published int64
+	for seq := 0; seq < p.count; seq++ {
+		for i, topic := range p.topics {
+			payload := probePayload(i, seq, time.Now())
+			// Subscribers expecting a checksum would count a bare probe as corrupted
+			if b.checksum != ChecksumNone {
+				payload = b.checksum.seal(payload)
+			}
+			err := client.Publish(topic, byte(b.qos), false, payload, func() {})
+			if err != nil {
+				b.logger.Error("Failed to publish probe message", logger.String("topic", topic), logger.ErrorAttr(err))
+				continue
+			}
+			published++
+		}
+	}
+
+	return published, nil
+}
+
+// probePayload encodes the probe topic number, sequence and send time
+func probePayload(topic, seq int, sent time.Time) []byte {
+	return fmt.Appendf(bytes.Clone(probePrefix), "%d|%d|%d", topic, seq, sent.UnixNano())
+}
+
+// probeKey identifies a probe message
+type probeKey struct {
+	topic int
+	seq   int
+}
+
+// parseProbe decodes a probe payload, reporting false for foreign payloads
+func parseProbe(payload []byte) (probeKey, time.Time, bool) {
+	rest, ok := bytes.CutPrefix(payload, probePrefix)
+	if !ok {
+		return probeKey{}, time.Time{}, false
+	}
+	fields := bytes.Split(rest, []byte("|"))
+	if len(fields) != 3 {
+		return probeKey{}, time.Time{}, false
+	}
+	topic, err1 := strconv.Atoi(string(fields[0]))
+	seq, err2 := strconv.Atoi(string(fields[1]))
+	sent, err3 := strconv.ParseInt(string(fields[2]), 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return probeKey{}, time.Time{}, false
+	}
+	return probeKey{topic: topic, seq: seq}, time.Unix(0, sent), true
+}
+
+// probeTracker verifies the probe messages received by a single subscriber
+type probeTracker struct {
+	mu         sync.Mutex
+	filters    []string
+	matching   map[int]bool // Probe topic numbers the filters should receive
+	expected   int
+	seen       map[probeKey]int
+	duplicates int
+	mismatched int
+	done       chan struct{} // Closed once every expected message arrived
+}
+
+// newTracker creates the tracker for a subscriber using the given filters
+func (p *probe) newTracker(filters ...string) *probeTracker {
+	t := &probeTracker{
+		filters:  filters,
+		matching: make(map[int]bool),
+		seen:     make(map[probeKey]int),
+		done:     make(chan struct{}),
+	}
+	for i, topic := range p.topics {
+		if t.matches(topic) {
+			t.matching[i] = true
+		}
+	}
+	t.expected = len(t.matching) * p.count
+	return t
+}
+
+// matches reports whether topic matches any of the tracker's filters
+func (t *probeTracker) matches(topic string) bool {
+	for _, filter := range t.filters {
+		if mqtt.MatchTopic(filter, topic) {
+			return true
+		}
+	}
+	return false
+}
+
+// observe records a delivered message and returns its publish time when it is
+// the first in-filter delivery of a probe message, so duplicates and
+// mismatches stay out of the delivery latency
+func (t *probeTracker) observe(msg mqtt.Message) (time.Time, bool) {
+	key, sent, ok := parseProbe(msg.Payload)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.matches(msg.Topic) || !t.matching[key.topic] {
+		t.mismatched++
+		return time.Time{}, false
+	}
+
+	t.seen[key]++
+	if t.seen[key] > 1 {
+		t.duplicates++
+		return time.Time{}, false
+	}
+	if len(t.seen) == t.expected {
+		close(t.done)
+	}
+	return sent, true
+}
+
+// add folds the tracker counts into the verification
+func (t *probeTracker) add(v *Verification) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	v.Expected += int64(t.expected)
+	v.Delivered += int64(len(t.seen))
+	v.Missing += int64(t.expected - len(t.seen))
+	v.Duplicates += int64(t.duplicates)
+	v.Mismatched += int64(t.mismatched)
+}
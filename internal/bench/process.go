@@ -0,0 +1,310 @@
+package bench
+
+import (
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rayomqio/benchmq/internal/mqtt"
+	"github.com/rayomqio/benchmq/pkg/er"
+	"github.com/rayomqio/benchmq/pkg/logger"
+)
+
+// ProcessTime describes how long a slow subscriber spends on each message
+type ProcessTime struct {
+	spec        string
+	min, max    time.Duration
+	exponential bool
+}
+
+// ParseProcessTime parses a processing time: a fixed duration ("10ms"), an
+// inclusive uniform range ("5ms-50ms") or an exponential mean ("exp:10ms")
+func ParseProcessTime(spec string) (*ProcessTime, error) {
+	pt := &ProcessTime{spec: spec}
+
+	var err error
+	switch {
+	case strings.HasPrefix(spec, "exp:"):
+		pt.min, err = time.ParseDuration(strings.TrimPrefix(spec, "exp:"))
+		pt.max = pt.min
+		pt.exponential = true
+	case strings.Contains(spec, "-"):
+		lo, hi, _ := strings.Cut(spec, "-")
+		if pt.min, err = time.ParseDuration(lo); err == nil {
+			pt.max, err = time.ParseDuration(hi)
+		}
+	default:
+		pt.min, err = time.ParseDuration(spec)
+		pt.max = pt.min
+	}
+	if err != nil || pt.min < 0 || pt.max < pt.min {
+		return nil, &er.Error{
+			Package: "Bench",
+			Func:    "ParseProcessTime",
+			Message: er.ErrInvalidProcessTime,
+			Raw:     fmt.Errorf("invalid process time %q", spec),
+		}
+	}
+	return pt, nil
+}
+
+// String returns the original specification
+func (pt *ProcessTime) String() string {
+	return pt.spec
+}
+
+// pick draws a processing time from the distribution
+func (pt *ProcessTime) pick(rng *rand.Rand) time.Duration {
+	switch {
+	case pt.exponential:
+		return time.Duration(rng.ExpFloat64() * float64(pt.min))
+	case pt.max > pt.min:
+		return pt.min + time.Duration(rng.Int64N(int64(pt.max-pt.min)+1))
+	default:
+		return pt.min
+	}
+}
+
+// Processing holds how fast and slow subscribers of the same topics fared
+type Processing struct {
+	ProcessTime string           `json:"processTime"`
+	QueueSize   int              `json:"queueSize"`
+	QueueDrop   bool             `json:"queueDrop"` // Full queues drop messages instead of stalling the connection
+	Slow        *SubscriberClass `json:"slow,omitempty"`
+	Fast        *SubscriberClass `json:"fast,omitempty"`
+}
+
+// SubscriberClass holds the results of the subscribers sharing a processing time
+type SubscriberClass struct {
+	Clients        int           `json:"clients"`
+	Received       int64         `json:"received"`
+	Dropped        int64         `json:"dropped"`     // Discarded by a full processing queue
+	Unprocessed    int64         `json:"unprocessed"` // Still queued when the subscriber left
+	Lost           int64         `json:"lost"`        // Tagged messages the broker never delivered
+	Disconnects    int64         `json:"disconnects"` // Connections the broker or network dropped
+	MaxQueueDepth  int64         `json:"maxQueueDepth"`
+	MeanQueueDepth float64       `json:"meanQueueDepth"`
+	Latency        *Latency      `json:"latency,omitempty"` // Publish until processed
+	LatencyFirst   time.Duration `json:"latencyFirst"`      // Mean over the first tenth of each subscriber's messages
+	LatencyLast    time.Duration `json:"latencyLast"`       // Mean over the last tenth of each subscriber's messages
+	LatencyGrowth  time.Duration `json:"latencyGrowth"`
+}
+
+// Attrs returns the processing settings as log attributes
+func (p *Processing) Attrs() []slog.Attr {
+	if p == nil {
+		return nil
+	}
+	return []slog.Attr{
+		logger.String("processTime", p.ProcessTime),
+		logger.Int("queueSize", p.QueueSize),
+		logger.Bool("queueDrop", p.QueueDrop),
+	}
+}
+
+// Attrs returns the class results as log attributes
+func (c *SubscriberClass) Attrs() []slog.Attr {
+	attrs := []slog.Attr{
+		logger.Int("clients", c.Clients),
+		logger.Any("received", c.Received),
+		logger.Any("dropped", c.Dropped),
+		logger.Any("unprocessed", c.Unprocessed),
+		logger.Any("lost", c.Lost),
+		logger.Any("disconnects", c.Disconnects),
+		logger.Any("maxQueueDepth", c.MaxQueueDepth),
+		logger.Float("meanQueueDepth", c.MeanQueueDepth),
+		logger.Float("latencyFirstMs", float64(c.LatencyFirst)/float64(time.Millisecond)),
+		logger.Float("latencyLastMs", float64(c.LatencyLast)/float64(time.Millisecond)),
+		logger.Float("latencyGrowthMs", float64(c.LatencyGrowth)/float64(time.Millisecond)),
+	}
+	return append(attrs, c.Latency.Attrs()...)
+}
+
+// isSlow reports whether the subscriber with the given index processes messages slowly
+func (b *Bench) isSlow(index int) bool {
+	return b.processTime != nil && (b.slowClients == 0 || index < b.slowClients)
+}
+
+// processor feeds a slow subscriber's messages through a bounded queue to a
+// single worker that spends the processing time on each of them. Messages are
+// queued by publish time, which is zero for untagged messages.
+type processor struct {
+	queue   chan time.Time
+	stopped chan struct{}
+	done    chan struct{}
+	handle  func(sent time.Time)
+	time    *ProcessTime
+	rng     *rand.Rand
+	drop    bool
+
+	dropped     atomic.Int64
+	unprocessed atomic.Int64
+	maxDepth    atomic.Int64
+	depthSum    atomic.Int64
+	depthCount  atomic.Int64
+}
+
+// newProcessor starts the processing worker of the client with the given index
+func (b *Bench) newProcessor(index int, handle func(sent time.Time)) *processor {
+	p := &processor{
+		queue:   make(chan time.Time, b.queueSize),
+		stopped: make(chan struct{}),
+		done:    make(chan struct{}),
+		handle:  handle,
+		time:    b.processTime,
+		rng:     newRand(b.seed, streamProcess, index),
+		drop:    b.queueDrop,
+	}
+	go p.run()
+	return p
+}
+
+// enqueue queues a message, blocking while the queue is full unless full queues drop
+func (p *processor) enqueue(sent time.Time) {
+	select {
+	case <-p.stopped:
+		p.unprocessed.Add(1)
+		return
+	default:
+	}
+
+	if p.drop {
+		select {
+		case p.queue <- sent:
+		default:
+			p.dropped.Add(1)
+			return
+		}
+	} else {
+		select {
+		case p.queue <- sent:
+		case <-p.stopped:
+			p.unprocessed.Add(1)
+			return
+		}
+	}
+
+	depth := int64(len(p.queue))
+	p.depthSum.Add(depth)
+	p.depthCount.Add(1)
+	for {
+		cur := p.maxDepth.Load()
+		if depth <= cur || p.maxDepth.CompareAndSwap(cur, depth) {
+			break
+		}
+	}
+}
+
+func (p *processor) run() {
+	defer close(p.done)
+	for {
+		select {
+		case <-p.stopped:
+			p.unprocessed.Add(int64(len(p.queue)))
+			return
+		case sent := <-p.queue:
+			time.Sleep(p.time.pick(p.rng))
+			if !sent.IsZero() {
+				p.handle(sent)
+			}
+		}
+	}
+}
+
+// stop ends the worker once its current message is processed; queued messages
+// count as unprocessed
+func (p *processor) stop() {
+	close(p.stopped)
+	<-p.done
+}
+
+// classTracker accumulates the results of one subscriber class
+type classTracker struct {
+	mu       sync.Mutex
+	clients  int
+	class    SubscriberClass
+	depthSum int64 // Queue depths seen on enqueue, for the mean
+	enqueued int64
+	latency  latencies
+	streams  map[int][]time.Duration // Processed latencies per subscriber, in order
+}
+
+func newClassTracker() *classTracker {
+	return &classTracker{streams: make(map[int][]time.Duration)}
+}
+
+// record adds the publish-to-processed latency of a message of subscriber index
+func (c *classTracker) record(index int, d time.Duration) {
+	c.latency.record(d)
+	c.mu.Lock()
+	c.streams[index] = append(c.streams[index], d)
+	c.mu.Unlock()
+}
+
+// add folds the counters of a finished subscriber into the class
+func (c *classTracker) add(received int64, client *mqtt.Adapter, p *processor, seq *sequenceTracker) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.clients++
+	c.class.Received += received
+	c.class.Disconnects += client.ConnectionsLost()
+	if seq != nil {
+		byQoS := make(map[byte]*Sequences)
+		seq.add(byQoS)
+		for _, s := range byQoS {
+			c.class.Lost += s.Lost
+		}
+	}
+	if p != nil {
+		c.class.Dropped += p.dropped.Load()
+		c.class.Unprocessed += p.unprocessed.Load()
+		c.class.MaxQueueDepth = max(c.class.MaxQueueDepth, p.maxDepth.Load())
+		c.depthSum += p.depthSum.Load()
+		c.enqueued += p.depthCount.Load()
+	}
+}
+
+// summarize returns the class results, or nil when no subscriber belonged to it
+func (c *classTracker) summarize() *SubscriberClass {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.clients == 0 {
+		return nil
+	}
+	out := c.class
+	out.Clients = c.clients
+	if c.enqueued > 0 {
+		out.MeanQueueDepth = float64(c.depthSum) / float64(c.enqueued)
+	}
+	out.Latency = c.latency.summarize()
+
+	var first, last time.Duration
+	var n int
+	for _, samples := range c.streams {
+		tenth := max(len(samples)/10, 1)
+		first += meanOf(samples[:tenth])
+		last += meanOf(samples[len(samples)-tenth:])
+		n++
+	}
+	if n > 0 {
+		out.LatencyFirst = first / time.Duration(n)
+		out.LatencyLast = last / time.Duration(n)
+		out.LatencyGrowth = out.LatencyLast - out.LatencyFirst
+	}
+	return &out
+}
+
+// meanOf returns the mean of samples, which must not be empty
+func meanOf(samples []time.Duration) time.Duration {
+	var total time.Duration
+	for _, s := range samples {
+		total += s
+	}
+	return total / time.Duration(len(samples))
+}
@@ -0,0 +1,67 @@
+package bench
+
+import (
+	"errors"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/rayomqio/benchmq/pkg/er"
+)
+
+func TestParseProcessTime(t *testing.T) {
+	tests := []struct {
+		spec        string
+		min, max    time.Duration
+		exponential bool
+		wantErr     bool
+	}{
+		{"0s", 0, 0, false, false},
+		{"5ms", 5 * time.Millisecond, 5 * time.Millisecond, false, false},
+		{"1ms-10ms", time.Millisecond, 10 * time.Millisecond, false, false},
+		{"2ms-2ms", 2 * time.Millisecond, 2 * time.Millisecond, false, false},
+		{"exp:3ms", 3 * time.Millisecond, 3 * time.Millisecond, true, false},
+		{"10ms-1ms", 0, 0, false, true},
+		{"-5ms", 0, 0, false, true},
+		{"1ms-", 0, 0, false, true},
+		{"exp:-1ms", 0, 0, false, true},
+		{"exp:", 0, 0, false, true},
+		{"5", 0, 0, false, true},
+		{"", 0, 0, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			pt, err := ParseProcessTime(tt.spec)
+			if tt.wantErr {
+				if !errors.Is(err, er.ErrInvalidProcessTime) {
+					t.Fatalf("ParseProcessTime(%q) error = %v, want ErrInvalidProcessTime", tt.spec, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pt.min != tt.min || pt.max != tt.max || pt.exponential != tt.exponential {
+				t.Errorf("ParseProcessTime(%q) = %+v, want min %s max %s exponential %v", tt.spec, pt, tt.min, tt.max, tt.exponential)
+			}
+			if pt.String() != tt.spec {
+				t.Errorf("String() = %q, want %q", pt.String(), tt.spec)
+			}
+		})
+	}
+}
+
+func TestProcessTimePick(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	ranged, _ := ParseProcessTime("1ms-3ms")
+	exp, _ := ParseProcessTime("exp:1ms")
+	for range 1000 {
+		if d := ranged.pick(rng); d < time.Millisecond || d > 3*time.Millisecond {
+			t.Fatalf("ranged pick %s outside 1ms-3ms", d)
+		}
+		if d := exp.pick(rng); d < 0 {
+			t.Fatalf("exponential pick %s is negative", d)
+		}
+	}
+}
@@ -2,25 +2,39 @@ package bench
 
 import (
 	"fmt"
-	"sync/atomic"
+	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/rayomqio/benchmq/internal/mqtt"
 	"github.com/rayomqio/benchmq/pkg/logger"
 )
 
-func (b *Bench) PublishMessages() {
+func (b *Bench) PublishMessages() *Summary {
 	start := time.Now()
-	b.logger.Info("Started publish benchmark", logger.String("start", start.Format(time.RFC3339Nano)))
+	b.logger.Info("Started publish benchmark",
+		logger.String("start", start.Format(time.RFC3339Nano)),
+		logger.String("arrival", b.describeArrival()),
+		logger.String("payload", b.describePayload()),
+		logger.Any("warmup", b.warmup.String()),
+		logger.Int("warmupCount", b.warmupCount),
+		logger.Bool("sequenceTag", b.sequenceTag),
+		logger.String("checksum", string(b.checksum)),
+		logger.Int("maxInflight", b.maxInflight),
+	)
+
+	// Messages sent during warm-up are tracked apart from the measured window
+	var warm, measured window
+	var used topicSet
 
-	var failed int32
-	var succeeded int32
+	pool := b.newConnectPool()
+	payloads := b.newPayloads()
 
 	for i := 0; i < b.clients; i++ {
 		b.wg.Add(1)
 
 		clientID := fmt.Sprintf("%s-%d", b.clientID, i)
-		go func(id string) {
+		go func(index int, id string) {
 			defer b.wg.Done()
 
 			cfg := *b.cfg
@@ -32,43 +46,169 @@ func (b *Bench) PublishMessages() {
 			client := mqtt.NewClient(&cfg)
 			b.logger.Info("Connecting Client", logger.ClientID(id), logger.State("connecting"))
 
-			if err := client.Connect(); err != nil {
-				atomic.AddInt32(&failed, int32(b.messageCount))
+			if err := pool.connect(client); err != nil {
+				// Charge each unsent message to the window it would have been sent in
+				elapsed := time.Since(start)
+				mean := b.meanDelay()
+				for j := 0; j < b.messageCount; j++ {
+					if b.inWarmup(j, elapsed+time.Duration(j)*mean) {
+						warm.failed.Add(1)
+					} else {
+						measured.failed.Add(1)
+					}
+				}
 				b.logger.Error("Client connection failed", logger.ClientID(id), logger.ErrorAttr(err))
 				return
 			}
 			defer client.Disconnect()
 
+			// Publishes are pipelined up to the in-flight window, acks complete them
+			client.SetPublishWindow(b.maxInflight, b.publishTimeout)
+			var pending sync.WaitGroup
+			defer pending.Wait()
+
+			pacer := b.newPacer(index)
+			stream := payloads.stream(b.seed, index, id)
+			topics := b.newTopics(index, id)
+			var seen clientTopics
+			defer used.merge(&seen)
+			// Waits count from a schedule rather than the last publish, so the time
+			// spent publishing doesn't slow the rate down
+			next := time.Now()
 			for j := 0; j < b.messageCount; j++ {
-				if b.delay > 0 {
-					time.Sleep(time.Duration(b.delay) * time.Millisecond)
+				next = next.Add(pacer.next())
+				if wait := time.Until(next); wait > 0 {
+					time.Sleep(wait)
 				}
 
-				err := client.Publish(b.topic, byte(b.qos), b.retained, b.message, func() {
-					atomic.AddInt32(&succeeded, 1)
-					b.logger.LogPublish(id, b.topic, int(b.qos), b.retained)
+				w := &measured
+				if b.inWarmup(j, time.Since(start)) {
+					w = &warm
+				}
+
+				payload, err := stream.next(j)
+				if err != nil {
+					w.failed.Add(1)
+					b.logger.Error("Failed to render message template", logger.ClientID(id), logger.ErrorAttr(err))
+					continue
+				}
+
+				topic, err := topics.next(j)
+				if err != nil {
+					w.failed.Add(1)
+					b.logger.Error("Failed to render topic template", logger.ClientID(id), logger.ErrorAttr(err))
+					continue
+				}
+				seen.add(topic)
+
+				// The sequence header is measurement overhead, left out of the bytes sent
+				tagged := 0
+				if b.sequenceTag {
+					payload = tagPayload(payload, id, j, b.messageCount, time.Now())
+					tagged = tagLen(payload)
+				}
+				if b.checksum != ChecksumNone {
+					payload = b.checksum.seal(payload)
+				}
+
+				// Rendering and waiting for a free slot are excluded from the publish latency
+				w.mark(time.Now())
+				pending.Add(1)
+				err = client.PublishAsync(topic, byte(b.qos), b.retained, payload, func(latency time.Duration, err error) {
+					defer pending.Done()
+					if err != nil {
+						w.failed.Add(1)
+						b.logger.Error("Failed to publish message", logger.ErrorAttr(err))
+						return
+					}
+					w.latencies.record(latency)
+					w.succeeded.Add(1)
+					w.bytes.Add(int64(len(payload) - tagged))
+					b.logger.LogPublish(id, topic, int(b.qos), b.retained)
 				})
 				if err != nil {
-					atomic.AddInt32(&failed, 1)
+					pending.Done()
+					w.failed.Add(1)
 					b.logger.Error("Failed to publish message", logger.ErrorAttr(err))
 				}
 			}
-		}(clientID)
+		}(i, clientID)
 	}
 
 	b.wg.Wait()
+	end := time.Now()
 
-	elapsed := time.Since(start).Seconds()
 	total := b.clients * b.messageCount
-	throughput := float64(total) / elapsed
+	// Without a warm-up the measured window is the whole run
+	topicCount, topicsCapped := used.count()
+	measuredStart := start
+	if b.warmup > 0 || b.warmupCount > 0 {
+		measuredStart = measured.started(start)
+	}
+	summary := &Summary{
+		Benchmark:    "pub",
+		Clients:      b.clients,
+		Succeeded:    measured.succeeded.Load(),
+		Failed:       measured.failed.Load(),
+		Bytes:        measured.bytes.Load(),
+		Topics:       topicCount,
+		TopicsCapped: topicsCapped,
+		Elapsed:      end.Sub(measuredStart),
+		Arrival:      b.describeArrival(),
+		Payload:      b.describePayload(),
+		Latency:      measured.latencies.summarize(),
+	}
+	summary.Expected = int64(total)
+	if b.warmup > 0 || b.warmupCount > 0 {
+		summary.Warmup = &Summary{
+			Benchmark: "pub-warmup",
+			Clients:   b.clients,
+			Succeeded: warm.succeeded.Load(),
+			Failed:    warm.failed.Load(),
+			Bytes:     warm.bytes.Load(),
+			Elapsed:   measuredStart.Sub(start),
+			Latency:   warm.latencies.summarize(),
+		}
+		summary.Warmup.Expected = summary.Warmup.Succeeded + summary.Warmup.Failed
+		summary.Expected -= summary.Warmup.Expected
+	}
 
-	b.logger.Info("Finished publish benchmark",
+	attrs := []slog.Attr{
 		logger.Int("clients", b.clients),
 		logger.Int("messagesPerClient", b.messageCount),
 		logger.Int("totalMessages", total),
-		logger.Int("successful", int(succeeded)),
-		logger.Int("failed", int(failed)),
-		logger.Float("elapsedSec", elapsed),
-		logger.Float("throughputMsgPerSec", throughput),
-	)
+		logger.Int("successful", int(summary.Succeeded)),
+		logger.Int("failed", int(summary.Failed)),
+		logger.Float("elapsedSec", summary.Elapsed.Seconds()),
+		logger.Float("throughputMsgPerSec", summary.Throughput()),
+		logger.Any("bytes", summary.Bytes),
+		logger.Float("throughputBytesPerSec", summary.BytesThroughput()),
+		logger.Int("distinctTopics", summary.Topics),
+		logger.Bool("distinctTopicsLowerBound", summary.TopicsCapped),
+		logger.String("arrival", summary.Arrival),
+		logger.String("payload", summary.Payload),
+		logger.Int("maxInflight", b.maxInflight),
+	}
+	attrs = append(attrs, summary.Latency.Attrs()...)
+	b.logger.Info("Finished publish benchmark", attrs...)
+
+	if summary.Warmup != nil {
+		attrs := []slog.Attr{
+			logger.Int("successful", int(summary.Warmup.Succeeded)),
+			logger.Int("failed", int(summary.Warmup.Failed)),
+			logger.Float("elapsedSec", summary.Warmup.Elapsed.Seconds()),
+			logger.Float("throughputMsgPerSec", summary.Warmup.Throughput()),
+			logger.Float("throughputBytesPerSec", summary.Warmup.BytesThroughput()),
+		}
+		attrs = append(attrs, summary.Warmup.Latency.Attrs()...)
+		b.logger.Info("Warm-up excluded from publish summary", attrs...)
+	}
+
+	return summary
+}
+
+// inWarmup reports whether the message with the given per-client sequence,
+// sent after elapsed time into the run, belongs to the warm-up window
+func (b *Bench) inWarmup(seq int, elapsed time.Duration) bool {
+	return seq < b.warmupCount || elapsed < b.warmup
 }
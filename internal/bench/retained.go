@@ -0,0 +1,238 @@
+package bench
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rayomqio/benchmq/internal/mqtt"
+	"github.com/rayomqio/benchmq/pkg/logger"
+)
+
+// Retained holds the outcome of seeding retained messages and delivering them
+// to new subscribers
+type Retained struct {
+	Seeded      int           `json:"seeded"`      // Distinct topics holding a retained message
+	SeedElapsed time.Duration `json:"seedElapsed"` // Time taken to publish the seed messages
+	Matching    int           `json:"matching"`    // Seeded topics matching the subscription filter
+	Complete    int64         `json:"complete"`    // Subscribers that received every matching message
+	Missing     int64         `json:"missing"`     // Matching messages never delivered, across subscribers
+	Cleared     int           `json:"cleared"`     // Topics cleared with empty retained publishes
+}
+
+// Attrs returns the retained results as log attributes
+func (r *Retained) Attrs() []slog.Attr {
+	if r == nil {
+		return nil
+	}
+	return []slog.Attr{
+		logger.Int("retainedSeeded", r.Seeded),
+		logger.Float("retainedSeedElapsedSec", r.SeedElapsed.Seconds()),
+		logger.Int("retainedMatching", r.Matching),
+		logger.Any("retainedComplete", r.Complete),
+		logger.Any("retainedMissing", r.Missing),
+		logger.Int("retainedCleared", r.Cleared),
+	}
+}
+
+// RetainedMessages seeds retained messages across the topic template, then
+// measures how long new wildcard subscribers take to receive all of them
+func (b *Bench) RetainedMessages() *Summary {
+	start := time.Now()
+	b.logger.Info("Started retained benchmark",
+		logger.String("start", start.Format(time.RFC3339Nano)),
+		logger.String("topic", b.topic),
+		logger.String("filter", b.retainedFilter),
+		logger.Int("count", b.messageCount),
+		logger.Bool("cleanup", b.retainedCleanup),
+	)
+
+	cfg := *b.cfg
+	cfg.Client.ClientID = b.clientID + "-seed"
+	cfg.Client.CleanSession = true
+	cfg.Client.KeepAlive = b.keepAlive
+	cfg.Client.Username = b.username
+	cfg.Client.Password = b.password
+	seeder := mqtt.NewClient(&cfg)
+	if err := seeder.Connect(); err != nil {
+		b.logger.Error("Seed client connection failed", logger.ErrorAttr(err))
+		return &Summary{Benchmark: "retained", Clients: b.clients, Failed: int64(b.clients)}
+	}
+	defer seeder.Disconnect()
+
+	seeded := b.seedRetained(seeder)
+	result := &Retained{Seeded: len(seeded), SeedElapsed: time.Since(start)}
+
+	// Only seeded topics count, other retained messages on the broker are ignored
+	matching := make(map[string]bool)
+	for _, topic := range seeded {
+		if mqtt.MatchTopic(b.retainedFilter, topic) {
+			matching[topic] = true
+		}
+	}
+	result.Matching = len(matching)
+
+	var delivered int64
+	var receivedBytes int64
+	var failed int64
+	var completion latencies
+
+	deliveryStart := time.Now()
+	pool := b.newConnectPool()
+
+	for i := 0; i < b.clients; i++ {
+		b.wg.Add(1)
+
+		clientID := fmt.Sprintf("%s-%d", b.clientID, i)
+		go func(id string) {
+			defer b.wg.Done()
+
+			cfg := *b.cfg
+			cfg.Client.ClientID = id
+			cfg.Client.CleanSession = *b.cleanSession
+			cfg.Client.KeepAlive = b.keepAlive
+			cfg.Client.Username = b.username
+			cfg.Client.Password = b.password
+			client := mqtt.NewClient(&cfg)
+
+			if err := pool.connect(client); err != nil {
+				atomic.AddInt64(&failed, 1)
+				b.logger.Error("Subscriber connection failed", logger.ClientID(id), logger.ErrorAttr(err))
+				return
+			}
+			defer client.Disconnect()
+
+			var mu sync.Mutex
+			seen := make(map[string]bool, len(matching))
+			done := make(chan struct{})
+			if len(matching) == 0 {
+				close(done)
+			}
+
+			subscribed := time.Now()
+			err := client.Subscribe(b.retainedFilter, byte(b.qos), false, func(msg mqtt.Message) {
+				if !msg.Retained || !matching[msg.Topic] {
+					return
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				if seen[msg.Topic] {
+					return
+				}
+				seen[msg.Topic] = true
+				atomic.AddInt64(&delivered, 1)
+				atomic.AddInt64(&receivedBytes, int64(len(msg.Payload)))
+				if len(seen) == len(matching) {
+					completion.record(msg.Received.Sub(subscribed))
+					close(done)
+				}
+			})
+			if err != nil {
+				atomic.AddInt64(&failed, 1)
+				b.logger.Error("Failed to subscribe", logger.ClientID(id), logger.ErrorAttr(err))
+				return
+			}
+
+			select {
+			case <-done:
+			case <-time.After(b.retainedTimeout):
+				b.logger.Warn("Timed out waiting for retained messages", logger.ClientID(id))
+			}
+
+			mu.Lock()
+			if len(seen) == len(matching) {
+				atomic.AddInt64(&result.Complete, 1)
+			}
+			atomic.AddInt64(&result.Missing, int64(len(matching)-len(seen)))
+			mu.Unlock()
+		}(clientID)
+	}
+
+	b.wg.Wait()
+	elapsed := time.Since(deliveryStart)
+
+	if b.retainedCleanup {
+		result.Cleared = b.clearRetained(seeder, seeded)
+	}
+
+	summary := &Summary{
+		Benchmark: "retained",
+		Clients:   b.clients,
+		Expected:  int64(b.clients) * int64(len(matching)),
+		Succeeded: delivered,
+		Failed:    failed,
+		Bytes:     receivedBytes,
+		Topics:    len(seeded),
+		Elapsed:   elapsed,
+		Payload:   b.describePayload(),
+		Latency:   completion.summarize(),
+		Retained:  result,
+	}
+
+	attrs := []slog.Attr{
+		logger.Int("clients", b.clients),
+		logger.Any("expectedDeliveries", summary.Expected),
+		logger.Any("delivered", delivered),
+		logger.Any("failed", failed),
+		logger.Float("elapsedSec", summary.Elapsed.Seconds()),
+		logger.Float("throughputMsgPerSec", summary.Throughput()),
+		logger.Any("bytes", summary.Bytes),
+	}
+	attrs = append(attrs, result.Attrs()...)
+	attrs = append(attrs, summary.Latency.AttrsNamed("complete")...)
+	b.logger.Info("Finished retained benchmark", attrs...)
+
+	return summary
+}
+
+// seedRetained publishes the configured number of retained messages and
+// returns the distinct topics that now hold one
+func (b *Bench) seedRetained(client *mqtt.Adapter) []string {
+	id := b.clientID + "-seed"
+	stream := b.newPayloads().stream(b.seed, 0, id)
+	topics := b.newTopics(0, id)
+
+	var seeded []string
+	used := make(map[string]bool)
+	for j := 0; j < b.messageCount; j++ {
+		payload, err := stream.next(j)
+		if err != nil {
+			b.logger.Error("Failed to render message template", logger.ClientID(id), logger.ErrorAttr(err))
+			continue
+		}
+		topic, err := topics.next(j)
+		if err != nil {
+			b.logger.Error("Failed to render topic template", logger.ClientID(id), logger.ErrorAttr(err))
+			continue
+		}
+
+		if err := client.Publish(topic, byte(b.qos), true, payload, func() {}); err != nil {
+			b.logger.Error("Failed to publish retained message", logger.String("topic", topic), logger.ErrorAttr(err))
+			continue
+		}
+		if !used[topic] {
+			used[topic] = true
+			seeded = append(seeded, topic)
+		}
+	}
+
+	return seeded
+}
+
+// clearRetained removes the retained message of every topic with an empty
+// retained publish and returns the number of topics cleared
+func (b *Bench) clearRetained(client *mqtt.Adapter, topics []string) int {
+	cleared := 0
+	for _, topic := range topics {
+		if err := client.Publish(topic, byte(b.qos), true, []byte{}, func() {}); err != nil {
+			b.logger.Error("Failed to clear retained message", logger.String("topic", topic), logger.ErrorAttr(err))
+			continue
+		}
+		cleared++
+	}
+	b.logger.Info("Cleared retained messages", logger.Int("topics", cleared))
+	return cleared
+}
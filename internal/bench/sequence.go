@@ -0,0 +1,249 @@
+package bench
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rayomqio/benchmq/internal/mqtt"
+	"github.com/rayomqio/benchmq/pkg/logger"
+)
+
+// seqPrefix marks payloads tagged with a publisher sequence header
+var seqPrefix = []byte("benchmq-seq|")
+
+// Sequences holds the per-publisher sequence checks of one delivered QoS level
+type Sequences struct {
+	QoS        byte  `json:"qos"`
+	Streams    int64 `json:"streams"`        // Publisher streams seen, across subscribers
+	Missing    int64 `json:"missingStreams"` // Expected publisher streams that delivered nothing
+	Expected   int64 `json:"expected"`       // Messages the publishers sent
+	Received   int64 `json:"received"`       // Distinct messages received
+	Lost       int64 `json:"lost"`           // Messages never received
+	Duplicates int64 `json:"duplicates"`     // Repeated deliveries of the same message
+	OutOfOrder int64 `json:"outOfOrder"`     // Messages received after a later one of the same publisher
+}
+
+// Attrs returns the sequence checks as log attributes
+func (s *Sequences) Attrs() []slog.Attr {
+	prefix := fmt.Sprintf("qos%d", s.QoS)
+	return []slog.Attr{
+		logger.Any(prefix+"Streams", s.Streams),
+		logger.Any(prefix+"MissingStreams", s.Missing),
+		logger.Any(prefix+"Expected", s.Expected),
+		logger.Any(prefix+"Received", s.Received),
+		logger.Any(prefix+"Lost", s.Lost),
+		logger.Any(prefix+"Duplicates", s.Duplicates),
+		logger.Any(prefix+"OutOfOrder", s.OutOfOrder),
+	}
+}
+
+// seqTag is the decoded sequence header of a tagged payload
+type seqTag struct {
+	pubID string
+	seq   int
+	total int
+	sent  time.Time
+}
+
+// tagPayload prepends the sequence header of the message to payload
+func tagPayload(payload []byte, pubID string, seq, total int, sent time.Time) []byte {
+	out := fmt.Appendf(bytes.Clone(seqPrefix), "%s|%d|%d|%d|", pubID, seq, total, sent.UnixNano())
+	return append(out, payload...)
+}
+
+// tagLen returns the length of the sequence header of a tagged payload, 0 for
+// untagged ones
+func tagLen(payload []byte) int {
+	rest, ok := bytes.CutPrefix(payload, seqPrefix)
+	if !ok {
+		return 0
+	}
+	n := len(seqPrefix)
+	for range 4 {
+		i := bytes.IndexByte(rest, '|')
+		if i < 0 {
+			return 0
+		}
+		n += i + 1
+		rest = rest[i+1:]
+	}
+	return n
+}
+
+// parseSeq decodes a tagged payload and returns the payload after the header,
+// reporting false for untagged payloads
+func parseSeq(payload []byte) (seqTag, []byte, bool) {
+	rest, ok := bytes.CutPrefix(payload, seqPrefix)
+	if !ok {
+		return seqTag{}, nil, false
+	}
+	fields := bytes.SplitN(rest, []byte("|"), 5)
+	if len(fields) != 5 {
+		return seqTag{}, nil, false
+	}
+	seq, err1 := strconv.Atoi(string(fields[1]))
+	total, err2 := strconv.Atoi(string(fields[2]))
+	sent, err3 := strconv.ParseInt(string(fields[3]), 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil || seq < 0 || seq >= total {
+		return seqTag{}, nil, false
+	}
+	return seqTag{pubID: string(fields[0]), seq: seq, total: total, sent: time.Unix(0, sent)}, fields[4], true
+}
+
+// seqStream tracks the messages of one publisher seen by one subscriber
+type seqStream struct {
+	qos        byte
+	seen       []bool
+	received   int
+	duplicates int
+	arrivals   []seqArrival // First deliveries, in callback order
+}
+
+// seqArrival pairs a sequence with its arrival order on the client
+type seqArrival struct {
+	order uint64
+	seq   int
+}
+
+// outOfOrder counts messages that arrived after a later message of the stream.
+// Callbacks run concurrently, so arrivals are put back in client order first.
+func (s *seqStream) outOfOrder() int {
+	slices.SortFunc(s.arrivals, func(a, b seqArrival) int { return cmp.Compare(a.order, b.order) })
+	n, last := 0, -1
+	for _, a := range s.arrivals {
+		if a.seq < last {
+			n++
+		}
+		last = max(last, a.seq)
+	}
+	return n
+}
+
+// sequenceTracker tracks the publisher sequences seen by a subscriber
+type sequenceTracker struct {
+	mu      sync.Mutex
+	streams map[string]*seqStream
+	// Publishers expected to deliver, so ones whose messages were all lost
+	// still count. Their messages number total, or as many as the tags of the
+	// other publishers carry when total is 0, and are reported under qos when
+	// no publisher delivered at all.
+	publishers int
+	total      int
+	qos        byte
+}
+
+func newSequenceTracker(publishers, total int, qos byte) *sequenceTracker {
+	return &sequenceTracker{
+		streams:    make(map[string]*seqStream),
+		publishers: publishers,
+		total:      total,
+		qos:        qos,
+	}
+}
+
+// observe records a delivered message and returns its publish time for tagged payloads
+func (t *sequenceTracker) observe(msg mqtt.Message) (time.Time, bool) {
+	tag, _, ok := parseSeq(msg.Payload)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s := t.streams[tag.pubID]
+	if s == nil {
+		s = &seqStream{qos: msg.QoS, seen: make([]bool, tag.total)}
+		t.streams[tag.pubID] = s
+	}
+	if tag.seq >= len(s.seen) {
+		return tag.sent, true
+	}
+
+	if s.seen[tag.seq] {
+		s.duplicates++
+		return tag.sent, true
+	}
+	s.seen[tag.seq] = true
+	s.received++
+	s.arrivals = append(s.arrivals, seqArrival{order: msg.Order, seq: tag.seq})
+	return tag.sent, true
+}
+
+// counts returns the distinct and duplicate deliveries across all publishers
+func (t *sequenceTracker) counts() (received, duplicates int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, s := range t.streams {
+		received += s.received
+		duplicates += s.duplicates
+	}
+	return received, duplicates
+}
+
+// add folds the tracker counts into the per-QoS results
+func (t *sequenceTracker) add(byQoS map[byte]*Sequences) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, s := range t.streams {
+		r := byQoS[s.qos]
+		if r == nil {
+			r = &Sequences{QoS: s.qos}
+			byQoS[s.qos] = r
+		}
+		r.Streams++
+		r.Expected += int64(len(s.seen))
+		r.Received += int64(s.received)
+		r.Lost += int64(len(s.seen) - s.received)
+		r.Duplicates += int64(s.duplicates)
+		r.OutOfOrder += int64(s.outOfOrder())
+	}
+
+	missing := t.publishers - len(t.streams)
+	if missing <= 0 {
+		return
+	}
+	total, qos := t.total, t.qos
+	first := true
+	for _, s := range t.streams {
+		if t.total == 0 {
+			total = max(total, len(s.seen))
+		}
+		if first || s.qos < qos {
+			qos, first = s.qos, false
+		}
+	}
+	r := byQoS[qos]
+	if r == nil {
+		r = &Sequences{QoS: qos}
+		byQoS[qos] = r
+	}
+	r.Missing += int64(missing)
+	r.Expected += int64(missing * total)
+	r.Lost += int64(missing * total)
+}
+
+// sequencesOf collects the per-QoS results of the trackers, ordered by QoS
+func sequencesOf(trackers []*sequenceTracker) []*Sequences {
+	byQoS := make(map[byte]*Sequences)
+	for _, t := range trackers {
+		if t != nil {
+			t.add(byQoS)
+		}
+	}
+
+	var out []*Sequences
+	for _, s := range byQoS {
+		out = append(out, s)
+	}
+	slices.SortFunc(out, func(a, b *Sequences) int { return int(a.QoS) - int(b.QoS) })
+	return out
+}
@@ -0,0 +1,87 @@
+package bench
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSeq(t *testing.T) {
+	sent := time.Unix(0, 1_700_000_000_123_456_789)
+
+	tests := []struct {
+		name    string
+		payload []byte
+		want    seqTag
+		body    string
+		ok      bool
+	}{
+		{"tagged", tagPayload([]byte("hello"), "pub-1", 3, 10, sent), seqTag{"pub-1", 3, 10, sent}, "hello", true},
+		{"empty body", tagPayload(nil, "p", 0, 1, sent), seqTag{"p", 0, 1, sent}, "", true},
+		{"body with separators", tagPayload([]byte("a|b|c"), "p", 1, 2, sent), seqTag{"p", 1, 2, sent}, "a|b|c", true},
+		{"untagged", []byte("hello"), seqTag{}, "", false},
+		{"missing fields", []byte("benchmq-seq|p|1|2"), seqTag{}, "", false},
+		{"bad seq", []byte("benchmq-seq|p|x|2|0|"), seqTag{}, "", false},
+		{"seq past total", []byte("benchmq-seq|p|2|2|0|"), seqTag{}, "", false},
+		{"negative seq", []byte("benchmq-seq|p|-1|2|0|"), seqTag{}, "", false},
+		{"bad time", []byte("benchmq-seq|p|0|2|now|"), seqTag{}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tag, body, ok := parseSeq(tt.payload)
+			if ok != tt.ok {
+				t.Fatalf("parseSeq(%q) ok = %v, want %v", tt.payload, ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if tag.pubID != tt.want.pubID || tag.seq != tt.want.seq || tag.total != tt.want.total || !tag.sent.Equal(tt.want.sent) {
+				t.Errorf("parseSeq(%q) tag = %+v, want %+v", tt.payload, tag, tt.want)
+			}
+			if string(body) != tt.body {
+				t.Errorf("parseSeq(%q) body = %q, want %q", tt.payload, body, tt.body)
+			}
+		})
+	}
+}
+
+func TestTagLen(t *testing.T) {
+	tagged := tagPayload([]byte("a|b"), "pub-1", 3, 10, time.Unix(0, 42))
+
+	tests := []struct {
+		name    string
+		payload []byte
+		want    int
+	}{
+		{"tagged", tagged, len(tagged) - len("a|b")},
+		{"untagged", []byte("hello|world|"), 0},
+		{"incomplete header", []byte("benchmq-seq|p|1|2"), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tagLen(tt.payload); got != tt.want {
+				t.Errorf("tagLen(%q) = %d, want %d", tt.payload, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOutOfOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		arrivals []seqArrival
+		want     int
+	}{
+		{"in order", []seqArrival{{1, 0}, {2, 1}, {3, 2}}, 0},
+		{"callbacks out of order", []seqArrival{{3, 2}, {1, 0}, {2, 1}}, 0},
+		{"one late", []seqArrival{{1, 0}, {2, 2}, {3, 1}}, 1},
+		{"reversed", []seqArrival{{1, 2}, {2, 1}, {3, 0}}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &seqStream{arrivals: tt.arrivals}
+			if got := s.outOfOrder(); got != tt.want {
+				t.Errorf("outOfOrder() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package bench
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rayomqio/benchmq/internal/mqtt"
+	"github.com/rayomqio/benchmq/pkg/logger"
+)
+
+// Session holds the outcome of queueing messages for offline persistent sessions
+type Session struct {
+	Backlog        int64         `json:"backlog"`         // Messages published while subscribers were offline
+	Queued         int64         `json:"queued"`          // Distinct backlog messages delivered after reconnecting
+	Lost           int64         `json:"lost"`            // Backlog messages never delivered, across subscribers
+	Duplicates     int64         `json:"duplicates"`      // Repeated deliveries of the same message
+	Drained        int64         `json:"drained"`         // Subscribers that received their whole backlog
+	DrainElapsed   time.Duration `json:"drainElapsed"`    // Reconnect until the last subscriber drained or timed out
+	DrainRate      float64       `json:"drainRatePerSec"` // Queued messages per second over the drain
+	SessionPresent int64         `json:"sessionPresent"`  // Reconnects whose CONNACK had session present set
+}
+
+// Attrs returns the session results as log attributes
+func (s *Session) Attrs() []slog.Attr {
+	if s == nil {
+		return nil
+	}
+	return []slog.Attr{
+		logger.Any("sessionBacklog", s.Backlog),
+		logger.Any("sessionQueued", s.Queued),
+		logger.Any("sessionLost", s.Lost),
+		logger.Any("sessionDuplicates", s.Duplicates),
+		logger.Any("sessionDrained", s.Drained),
+		logger.Float("sessionDrainElapsedSec", s.DrainElapsed.Seconds()),
+		logger.Float("sessionDrainRatePerSec", s.DrainRate),
+		logger.Any("sessionPresent", s.SessionPresent),
+	}
+}
+
+// PersistentSession subscribes with clean session off, disconnects the
+// subscribers, publishes a backlog and measures how the broker delivers the
+// queued messages once the same client IDs reconnect
+func (b *Bench) PersistentSession() *Summary {
+	start := time.Now()
+	b.logger.Info("Started persistent session benchmark",
+		logger.String("start", start.Format(time.RFC3339Nano)),
+		logger.String("topic", b.topic),
+		logger.Int("qos", int(b.qos)),
+		logger.Int("backlog", b.messageCount),
+	)
+	if b.qos == QoS0 {
+		b.logger.Warn("Brokers need not queue QoS 0 messages for offline sessions")
+	}
+
+	var failed int64
+	pool := b.newConnectPool()
+	subscribed := make([]bool, b.clients)
+
+	// Discard sessions left by earlier runs, then subscribe with a persistent session
+	for i := 0; i < b.clients; i++ {
+		b.wg.Add(1)
+		go func(index int) {
+			defer b.wg.Done()
+
+			id := fmt.Sprintf("%s-%d", b.clientID, index)
+			if err := b.resetSession(pool, id); err != nil {
+				atomic.AddInt64(&failed, 1)
+				b.logger.Error("Failed to discard stored session", logger.ClientID(id), logger.ErrorAttr(err))
+				return
+			}
+
+			client := b.newSessionClient(id, false)
+			if err := pool.connect(client); err != nil {
+				atomic.AddInt64(&failed, 1)
+				b.logger.Error("Subscriber connection failed", logger.ClientID(id), logger.ErrorAttr(err))
+				return
+			}
+			err := client.Subscribe(b.topic, byte(b.qos), false, func(mqtt.Message) {})
+			client.Disconnect()
+			if err != nil {
+				atomic.AddInt64(&failed, 1)
+				b.logger.Error("Failed to subscribe", logger.ClientID(id), logger.ErrorAttr(err))
+				return
+			}
+			subscribed[index] = true
+		}(i)
+	}
+	b.wg.Wait()
+
+	result := &Session{Backlog: b.publishBacklog()}
+
+	var delivery latencies
+	trackers := make([]*sequenceTracker, b.clients)
+
+	drainStart := time.Now()
+	for i := 0; i < b.clients; i++ {
+		if !subscribed[i] {
+			continue
+		}
+		b.wg.Add(1)
+		go func(index int) {
+			defer b.wg.Done()
+
+			id := fmt.Sprintf("%s-%d", b.clientID, index)
+			// The backlog publisher is expected even when none of its messages arrive
+			publishers := 0
+			if result.Backlog > 0 {
+				publishers = 1
+			}
+			tracker := newSequenceTracker(publishers, int(result.Backlog), byte(b.qos))
+			trackers[index] = tracker
+
+			var once sync.Once
+			done := make(chan struct{})
+			if result.Backlog == 0 {
+				close(done)
+			}
+
+			client := b.newSessionClient(id, false)
+			client.Route(b.topic, func(msg mqtt.Message) {
+				sent, ok := tracker.observe(msg)
+				if !ok {
+					return
+				}
+				delivery.record(msg.Received.Sub(sent))
+				if received, _ := tracker.counts(); int64(received) == result.Backlog {
+					once.Do(func() { close(done) })
+				}
+			})
+			if err := pool.connect(client); err != nil {
+				atomic.AddInt64(&failed, 1)
+				b.logger.Error("Subscriber reconnection failed", logger.ClientID(id), logger.ErrorAttr(err))
+				return
+			}
+			if client.SessionPresent() {
+				atomic.AddInt64(&result.SessionPresent, 1)
+			}
+
+			select {
+			case <-done:
+				atomic.AddInt64(&result.Drained, 1)
+			case <-time.After(b.sessionTimeout):
+				b.logger.Warn("Timed out waiting for queued messages", logger.ClientID(id))
+			}
+			client.Disconnect()
+		}(i)
+	}
+	b.wg.Wait()
+	result.DrainElapsed = time.Since(drainStart)
+
+	// Leave no stored sessions behind
+	for i := 0; i < b.clients; i++ {
+		b.wg.Add(1)
+		go func(id string) {
+			defer b.wg.Done()
+			if err := b.resetSession(pool, id); err != nil {
+				b.logger.Error("Failed to discard stored session", logger.ClientID(id), logger.ErrorAttr(err))
+			}
+		}(fmt.Sprintf("%s-%d", b.clientID, i))
+	}
+	b.wg.Wait()
+
+	summary := &Summary{
+		Benchmark: "session",
+		Clients:   b.clients,
+		Failed:    failed,
+		Elapsed:   result.DrainElapsed,
+		Latency:   delivery.summarize(),
+		Sequences: sequencesOf(trackers),
+		Session:   result,
+	}
+	for i, t := range trackers {
+		if t == nil {
+			continue
+		}
+		received, duplicates := t.counts()
+		summary.Expected += result.Backlog
+		result.Queued += int64(received)
+		result.Lost += result.Backlog - int64(received)
+		result.Duplicates += int64(duplicates)
+		b.logger.Debug("Subscriber drained", logger.ClientID(fmt.Sprintf("%s-%d", b.clientID, i)), logger.Int("queued", received))
+	}
+	summary.Succeeded = result.Queued
+	if result.DrainElapsed > 0 {
+		result.DrainRate = float64(result.Queued) / result.DrainElapsed.Seconds()
+	}
+
+	attrs := []slog.Attr{
+		logger.Int("clients", b.clients),
+		logger.Any("expected", summary.Expected),
+		logger.Any("failed", failed),
+	}
+	attrs = append(attrs, result.Attrs()...)
+	attrs = append(attrs, summary.Latency.Attrs()...)
+	b.logger.Info("Finished persistent session benchmark", attrs...)
+
+	return summary
+}
+
+// publishBacklog publishes the tagged backlog from a dedicated client and
+// returns the number of messages the broker accepted
+func (b *Bench) publishBacklog() int64 {
+	id := b.clientID + "-pub"
+	client := b.newSessionClient(id, true)
+	if err := client.Connect(); err != nil {
+		b.logger.Error("Backlog publisher connection failed", logger.ErrorAttr(err))
+		return 0
+	}
+	defer client.Disconnect()
+
+	stream := b.newPayloads().stream(b.seed, 0, id)
+	var published int64
+	for j := 0; j < b.messageCount; j++ {
+		payload, err := stream.next(j)
+		if err != nil {
+			b.logger.Error("Failed to render message template", logger.ClientID(id), logger.ErrorAttr(err))
+			continue
+		}
+		payload = tagPayload(payload, id, j, b.messageCount, time.Now())
+		if err := client.Publish(b.topic, byte(b.qos), false, payload, func() {}); err != nil {
+			b.logger.Error("Failed to publish backlog message", logger.ErrorAttr(err))
+			continue
+		}
+		published++
+	}
+
+	b.logger.Info("Published backlog", logger.Any("messages", published))
+	return published
+}
+
+// resetSession connects once with a clean session so the broker discards
+// anything stored for the client ID
+func (b *Bench) resetSession(pool *connectPool, id string) error {
+	client := b.newSessionClient(id, true)
+	if err := pool.connect(client); err != nil {
+		return err
+	}
+	client.Disconnect()
+	return nil
+}
+
+// newSessionClient creates a client with the given clean session flag
+func (b *Bench) newSessionClient(id string, clean bool) *mqtt.Adapter {
+	cfg := *b.cfg
+	cfg.Client.ClientID = id
+	cfg.Client.CleanSession = clean
+	cfg.Client.KeepAlive = b.keepAlive
+	cfg.Client.Username = b.username
+	cfg.Client.Password = b.password
+	return mqtt.NewClient(&cfg)
+}
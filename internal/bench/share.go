@@ -0,0 +1,105 @@
+package bench
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+
+	"github.com/rayomqio/benchmq/pkg/logger"
+)
+
+// ShareGroup holds how messages were spread across the members of a shared subscription
+type ShareGroup struct {
+	Filter  string  `json:"filter"`  // Full $share/<group>/<filter> subscriptions, comma separated
+	Members []int64 `json:"members"` // Messages received per member
+	Min     int64   `json:"min"`
+	Max     int64   `json:"max"`
+	Mean    float64 `json:"mean"`
+	StdDev  float64 `json:"stddev"`
+	// Probe deliveries across the whole group, set when a probe ran
+	Verification *Verification `json:"verification,omitempty"`
+}
+
+// shareGroupName returns the share group of the client with the given index
+func (b *Bench) shareGroupName(index int) string {
+	if b.shareGroups <= 1 {
+		return b.shareGroup
+	}
+	return fmt.Sprintf("%s-%d", b.shareGroup, index%b.shareGroups)
+}
+
+// shareFilter prefixes filter with the client's shared subscription group
+func (b *Bench) shareFilter(index int, filter string) string {
+	if b.shareGroup == "" {
+		return filter
+	}
+	return fmt.Sprintf("$share/%s/%s", b.shareGroupName(index), filter)
+}
+
+// shareGroupsOf groups the per-client message counts by shared subscription
+// filters, in order of first member. Group verifications start from the probe
+// totals in v, which is nil when no probe ran.
+func shareGroupsOf(keys []string, counts []int64, trackers map[string]*probeTracker, v *Verification) []*ShareGroup {
+	var order []string
+	members := make(map[string][]int64)
+	for i, filter := range keys {
+		// Clients that never subscribed have no filter
+		if filter == "" {
+			continue
+		}
+		if _, ok := members[filter]; !ok {
+			order = append(order, filter)
+		}
+		members[filter] = append(members[filter], counts[i])
+	}
+
+	groups := make([]*ShareGroup, 0, len(order))
+	for _, filter := range order {
+		g := newShareGroup(filter, members[filter])
+		if t := trackers[filter]; t != nil && v != nil {
+			g.Verification = &Verification{Topics: v.Topics, Published: v.Published}
+			t.add(g.Verification)
+		}
+		groups = append(groups, g)
+	}
+	return groups
+}
+
+// newShareGroup computes the distribution of per-member message counts
+func newShareGroup(filter string, members []int64) *ShareGroup {
+	g := &ShareGroup{Filter: filter, Members: members}
+	if len(members) == 0 {
+		return g
+	}
+
+	g.Min, g.Max = members[0], members[0]
+	var sum float64
+	for _, m := range members {
+		g.Min = min(g.Min, m)
+		g.Max = max(g.Max, m)
+		sum += float64(m)
+	}
+	g.Mean = sum / float64(len(members))
+
+	var sq float64
+	for _, m := range members {
+		sq += (float64(m) - g.Mean) * (float64(m) - g.Mean)
+	}
+	g.StdDev = math.Sqrt(sq / float64(len(members)))
+
+	return g
+}
+
+// Attrs returns the group distribution as log attributes
+func (g *ShareGroup) Attrs() []slog.Attr {
+	attrs := []slog.Attr{
+		logger.String("filter", g.Filter),
+		logger.Int("members", len(g.Members)),
+		logger.Any("perMember", g.Members),
+		logger.Any("min", g.Min),
+		logger.Any("max", g.Max),
+		logger.Float("mean", g.Mean),
+		logger.Float("stddev", g.StdDev),
+	}
+	return append(attrs, g.Verification.Attrs()...)
+}
@@ -0,0 +1,95 @@
+package bench
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Latency holds the distribution of operation latencies in a window
+type Latency struct {
+	Samples int           `json:"samples"`
+	Mean    time.Duration `json:"mean"`
+	P50     time.Duration `json:"p50"`
+	P90     time.Duration `json:"p90"`
+	P99     time.Duration `json:"p99"`
+	Max     time.Duration `json:"max"`
+}
+
+// latencies records latency samples from concurrent clients
+type latencies struct {
+	mu      sync.Mutex
+	samples []time.Duration
+}
+
+func (l *latencies) record(d time.Duration) {
+	l.mu.Lock()
+	l.samples = append(l.samples, d)
+	l.mu.Unlock()
+}
+
+// summarize computes the distribution, or nil when nothing was recorded
+func (l *latencies) summarize() *Latency {
+	l.mu.Lock()
+	samples := slices.Clone(l.samples)
+	l.mu.Unlock()
+
+	if len(samples) == 0 {
+		return nil
+	}
+	slices.Sort(samples)
+
+	var total time.Duration
+	for _, s := range samples {
+		total += s
+	}
+
+	return &Latency{
+		Samples: len(samples),
+		Mean:    total / time.Duration(len(samples)),
+		P50:     percentile(samples, 50),
+		P90:     percentile(samples, 90),
+		P99:     percentile(samples, 99),
+		Max:     samples[len(samples)-1],
+	}
+}
+
+// percentile returns the nearest-rank percentile p of sorted samples
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(p/100*float64(len(sorted))+0.5) - 1
+	rank = max(0, min(rank, len(sorted)-1))
+	return sorted[rank]
+}
+
+// window collects counters and latencies for one measurement window
+type window struct {
+	succeeded atomic.Int64
+	failed    atomic.Int64
+	bytes     atomic.Int64
+	latencies latencies
+	// Unix nanoseconds of the first operation in the window
+	first atomic.Int64
+}
+
+// mark records t as the window start if it precedes every earlier operation
+func (w *window) mark(t time.Time) {
+	ns := t.UnixNano()
+	for {
+		cur := w.first.Load()
+		if cur != 0 && cur <= ns {
+			return
+		}
+		if w.first.CompareAndSwap(cur, ns) {
+			return
+		}
+	}
+}
+
+// started returns the window start, or fallback when nothing was recorded
+func (w *window) started(fallback time.Time) time.Time {
+	if ns := w.first.Load(); ns != 0 {
+		return time.Unix(0, ns)
+	}
+	return fallback
+}
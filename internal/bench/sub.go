@@ -2,6 +2,9 @@ package bench
 
 import (
 	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -9,19 +12,69 @@ import (
 	"github.com/rayomqio/benchmq/pkg/logger"
 )
 
-func (b *Bench) Subscribe() {
+func (b *Bench) Subscribe() *Summary {
 	start := time.Now()
-	b.logger.Info("Started subscribe benchmark", logger.String("start", start.Format(time.RFC3339Nano)))
+	b.logger.Info("Started subscribe benchmark",
+		logger.String("start", start.Format(time.RFC3339Nano)),
+		logger.Int("probeTopics", b.probeTopics),
+		logger.Int("probeCount", b.probeCount),
+		logger.String("shareGroup", b.shareGroup),
+		logger.Int("shareGroups", b.shareGroups),
+		logger.Int("subscriptionsPerClient", b.subscriptions),
+		logger.Bool("singleSubscribe", b.singleSubscribe),
+		logger.String("processTime", b.processTimeSpec),
+		logger.Int("slowClients", b.slowClients),
+	)
 
 	var received int64
+	var receivedBytes int64
 	var failed int64
+	var subscriptions int64
+	var used topicSet
+	var delivery latencies
+	var subacks latencies
+	integrity := integrityCounter{expect: b.checksum}
+
+	// The probe publishes a known message set once every subscriber is ready
+	var prb *probe
+	if b.probeCount > 0 {
+		p, err := b.newProbe()
+		if err != nil {
+			b.logger.Error("Failed to prepare probe", logger.ErrorAttr(err))
+			return &Summary{Benchmark: "sub", Clients: b.clients, Failed: int64(b.clients)}
+		}
+		prb = p
+	}
+	trackers := make([]*probeTracker, b.clients)
+
+	// Members of a share group receive one copy of each message between them,
+	// so they share a tracker keyed by the client's shared subscription filters
+	members := make([]int64, b.clients)
+	keys := make([]string, b.clients)
+	shared := make(map[string]*probeTracker)
+	var sharedMu sync.Mutex
+
+	// Tagged publisher sequences are tracked per subscriber, or per share group
+	sequences := make([]*sequenceTracker, b.clients)
+	sharedSequences := make(map[string]*sequenceTracker)
+
+	// Slow subscribers process messages through a bounded queue, and are
+	// compared against the fast ones on the same topics
+	slow, fast := newClassTracker(), newClassTracker()
+
+	var ready sync.WaitGroup
+	ready.Add(b.clients)
+
+	pool := b.newConnectPool()
 
 	for i := 0; i < b.clients; i++ {
 		b.wg.Add(1)
 
 		clientID := fmt.Sprintf("%s-%d", b.clientID, i)
-		go func(id string) {
+		go func(index int, id string) {
 			defer b.wg.Done()
+			subscribed := sync.OnceFunc(ready.Done)
+			defer subscribed()
 
 			cfg := *b.cfg
 			cfg.Client.ClientID = id
@@ -30,44 +83,250 @@ func (b *Bench) Subscribe() {
 			cfg.Client.Username = b.username
 			cfg.Client.Password = b.password
 			client := mqtt.NewClient(&cfg)
+			class := fast
+			if b.isSlow(index) {
+				class = slow
+				client.SetBlocking(true)
+			}
 
 			b.logger.Info("Connecting subscriber", logger.ClientID(id), logger.State("connecting"))
-			if err := client.Connect(); err != nil {
+			if err := pool.connect(client); err != nil {
 				atomic.AddInt64(&failed, 1)
 				b.logger.Error("Subscriber connection failed", logger.ClientID(id), logger.ErrorAttr(err))
 				return
 			}
 			defer client.Disconnect()
 
-			err := client.Subscribe(b.topic, byte(b.qos), b.retained, func(payload string) {
+			// Each client subscribes to its own renderings of the topic template
+			topics := b.newTopics(index, id)
+			var seen clientTopics
+			defer used.merge(&seen)
+			// Renderings that repeat a filter would only replace the subscription, so
+			// each distinct filter is subscribed and counted once
+			filters := make([]string, 0, b.subscriptions)
+			unique := make(map[string]struct{}, b.subscriptions)
+			for k := 0; k < b.subscriptions; k++ {
+				topic, err := topics.sub(k)
+				if err != nil {
+					atomic.AddInt64(&failed, 1)
+					b.logger.Error("Failed to render topic template", logger.ClientID(id), logger.ErrorAttr(err))
+					return
+				}
+				seen.add(topic)
+				filter := b.shareFilter(index, topic)
+				if _, ok := unique[filter]; ok {
+					continue
+				}
+				unique[filter] = struct{}{}
+				filters = append(filters, filter)
+			}
+			key := strings.Join(filters, ",")
+			keys[index] = key
+
+			var tracker *probeTracker
+			if prb != nil {
+				sharedMu.Lock()
+				tracker = shared[key]
+				if tracker == nil {
+					tracker = prb.newTracker(filters...)
+					trackers[index] = tracker
+					if b.shareGroup != "" {
+						shared[key] = tracker
+					}
+				}
+				sharedMu.Unlock()
+			}
+
+			sharedMu.Lock()
+			sequence := sharedSequences[key]
+			if sequence == nil {
+				sequence = newSequenceTracker(b.publishers, 0, byte(b.qos))
+				sequences[index] = sequence
+				if b.shareGroup != "" {
+					sharedSequences[key] = sequence
+				}
+			}
+			sharedMu.Unlock()
+
+			var proc *processor
+			defer func() {
+				class.add(atomic.LoadInt64(&members[index]), client, proc, sequences[index])
+			}()
+			processed := func(sent time.Time) {
+				class.record(index, time.Since(sent))
+			}
+			if class == slow {
+				proc = b.newProcessor(index, processed)
+				// Stops before the deferred disconnect, which waits for a blocked enqueue
+				defer proc.stop()
+			}
+
+			err := b.subscribeAll(client, filters, &subacks, func(msg mqtt.Message) {
 				atomic.AddInt64(&received, 1)
-				b.logger.LogSubscribe(id, b.topic, int(b.qos), logger.String("payload", payload))
+				atomic.AddInt64(&members[index], 1)
+				size := len(msg.Payload)
+				// Checksummed payloads are verified, then checked without their header
+				msg.Payload = integrity.verify(msg.Payload)
+				// Sequence headers are measurement overhead, left out of the bytes received
+				atomic.AddInt64(&receivedBytes, int64(size-tagLen(msg.Payload)))
+				var sent time.Time
+				if tracker != nil {
+					if at, ok := tracker.observe(msg); ok {
+						delivery.record(msg.Received.Sub(at))
+						sent = at
+					}
+				}
+				if at, ok := sequence.observe(msg); ok {
+					delivery.record(msg.Received.Sub(at))
+					sent = at
+				}
+				b.logger.LogSubscribe(id, msg.Topic, int(msg.QoS), logger.String("payload", string(msg.Payload)))
+
+				// Slow subscribers queue the message for processing
+				if proc != nil {
+					proc.enqueue(sent)
+				} else if !sent.IsZero() {
+					processed(sent)
+				}
 			})
 			if err != nil {
 				atomic.AddInt64(&failed, 1)
 				b.logger.Error("Failed to subscribe", logger.ClientID(id), logger.ErrorAttr(err))
 				return
 			}
+			atomic.AddInt64(&subscriptions, int64(len(filters)))
+			subscribed()
 
-			if b.delay > 0 {
-				time.Sleep(time.Duration(b.delay) * time.Millisecond * time.Duration(b.messageCount))
-			} else {
-				time.Sleep(time.Second * 5)
+			// Probe subscribers leave as soon as every expected message arrived
+			lifetime := time.After(b.subscriptionLifetime())
+			if tracker != nil && tracker.expected > 0 {
+				select {
+				case <-tracker.done:
+				case <-lifetime:
+				}
+				return
 			}
-		}(clientID)
+			<-lifetime
+		}(i, clientID)
+	}
+
+	var published int64
+	if prb != nil {
+		ready.Wait()
+		n, err := prb.publish(b)
+		if err != nil {
+			b.logger.Error("Probe publisher failed", logger.ErrorAttr(err))
+		}
+		published = n
 	}
 
 	b.wg.Wait()
 
-	elapsed := time.Since(start).Seconds()
 	expected := int64(b.clients) * int64(b.messageCount)
-	throughput := float64(received) / elapsed
-	b.logger.Info("Finished subscribe benchmark",
+	topicCount, topicsCapped := used.count()
+	summary := &Summary{
+		Benchmark:    "sub",
+		Clients:      b.clients,
+		Expected:     expected,
+		Succeeded:    received,
+		Failed:       failed,
+		Bytes:        receivedBytes,
+		Topics:       topicCount,
+		TopicsCapped: topicsCapped,
+		Elapsed:      time.Since(start),
+		Latency:      delivery.summarize(),
+
+		Subscriptions: subscriptions,
+		Suback:        subacks.summarize(),
+	}
+	if prb != nil {
+		summary.Verification = &Verification{Topics: len(prb.topics), Published: published}
+		for _, t := range trackers {
+			if t != nil {
+				t.add(summary.Verification)
+			}
+		}
+		summary.Expected = summary.Verification.Expected
+	}
+	summary.Sequences = sequencesOf(sequences)
+	summary.Integrity = integrity.summarize()
+	if b.processTime != nil {
+		summary.Processing = &Processing{
+			ProcessTime: b.processTime.String(),
+			QueueSize:   b.queueSize,
+			QueueDrop:   b.queueDrop,
+			Slow:        slow.summarize(),
+			Fast:        fast.summarize(),
+		}
+	}
+	if b.shareGroup != "" {
+		summary.Shares = shareGroupsOf(keys, members, shared, summary.Verification)
+	}
+
+	attrs := []slog.Attr{
 		logger.Int("clients", b.clients),
-		logger.Any("expectedMessages", expected),
+		logger.Any("expectedMessages", summary.Expected),
 		logger.Any("received", received),
 		logger.Any("failed", failed),
-		logger.Float("elapsedSec", elapsed),
-		logger.Float("throughputMsgPerSec", throughput),
-	)
+		logger.Float("elapsedSec", summary.Elapsed.Seconds()),
+		logger.Float("throughputMsgPerSec", summary.Throughput()),
+		logger.Any("bytes", summary.Bytes),
+		logger.Float("throughputBytesPerSec", summary.BytesThroughput()),
+		logger.Int("distinctTopics", summary.Topics),
+		logger.Bool("distinctTopicsLowerBound", summary.TopicsCapped),
+		logger.Any("subscriptions", summary.Subscriptions),
+	}
+	attrs = append(attrs, summary.Suback.AttrsNamed("suback")...)
+	attrs = append(attrs, summary.Verification.Attrs()...)
+	for _, seq := range summary.Sequences {
+		attrs = append(attrs, seq.Attrs()...)
+	}
+	attrs = append(attrs, summary.Integrity.Attrs()...)
+	attrs = append(attrs, summary.Processing.Attrs()...)
+	attrs = append(attrs, summary.Latency.Attrs()...)
+	b.logger.Info("Finished subscribe benchmark", attrs...)
+
+	for _, g := range summary.Shares {
+		b.logger.Info("Share group distribution", g.Attrs()...)
+	}
+	if p := summary.Processing; p != nil {
+		if p.Slow != nil {
+			b.logger.Info("Slow subscribers", p.Slow.Attrs()...)
+		}
+		if p.Fast != nil {
+			b.logger.Info("Fast subscribers", p.Fast.Attrs()...)
+		}
+	}
+
+	return summary
+}
+
+// subscriptionLifetime returns how long subscribers stay connected
+func (b *Bench) subscriptionLifetime() time.Duration {
+	if delay := b.meanDelay(); delay > 0 {
+		return delay * time.Duration(b.messageCount)
+	}
+	return time.Second * 5
+}
+
+// subscribeAll subscribes the client to every filter, in a single SUBSCRIBE
+// packet when requested, and records the SUBACK latency of each packet
+func (b *Bench) subscribeAll(client *mqtt.Adapter, filters []string, subacks *latencies, callback func(msg mqtt.Message)) error {
+	if b.singleSubscribe {
+		sent := time.Now()
+		if err := client.SubscribeMultiple(filters, byte(b.qos), b.retained, callback); err != nil {
+			return err
+		}
+		subacks.record(time.Since(sent))
+		return nil
+	}
+
+	for _, filter := range filters {
+		sent := time.Now()
+		if err := client.Subscribe(filter, byte(b.qos), b.retained, callback); err != nil {
+			return err
+		}
+		subacks.record(time.Since(sent))
+	}
+	return nil
 }
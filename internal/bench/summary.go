@@ -0,0 +1,174 @@
+package bench
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/rayomqio/benchmq/pkg/logger"
+)
+
+// Summary holds the outcome of a single benchmark run
+type Summary struct {
+	Benchmark string `json:"benchmark"`
+	Clients   int    `json:"clients"`
+	Expected  int64  `json:"expected"`
+	Succeeded int64  `json:"succeeded"`
+	Failed    int64  `json:"failed"`
+	Bytes     int64  `json:"bytes"`
+	Topics    int    `json:"topics,omitempty"` // Distinct topics published or subscribed to
+	// Topics is a lower bound, distinct topic tracking was capped
+	TopicsCapped bool          `json:"topicsCapped,omitempty"`
+	Elapsed      time.Duration `json:"elapsed"`
+	Arrival      string        `json:"arrival,omitempty"`
+	Payload      string        `json:"payload,omitempty"`
+	Latency      *Latency      `json:"latency,omitempty"`
+	// Keepalive round trips, set by connection runs that hold connections
+	Pings *Pings `json:"pings,omitempty"`
+	// Probe delivery checks, set by subscribe runs with a probe
+	Verification *Verification `json:"verification,omitempty"`
+	// Subscriptions made and their SUBACK latency, set by subscribe runs
+	Subscriptions int64    `json:"subscriptions,omitempty"`
+	Suback        *Latency `json:"suback,omitempty"`
+	// Retained message seeding and delivery, set by retained runs
+	Retained *Retained `json:"retained,omitempty"`
+	// Will delivery after ungraceful disconnects, set by will runs
+	Wills *Wills `json:"wills,omitempty"`
+	// Offline queue delivery, set by persistent session runs
+	Session *Session `json:"session,omitempty"`
+	// Per-QoS checks of tagged publisher sequences, set by subscribe runs
+	Sequences []*Sequences `json:"sequences,omitempty"`
+	// Checksum verification of payloads, set by subscribe runs
+	Integrity *Integrity `json:"integrity,omitempty"`
+	// Fast versus slow subscribers, set when subscribers simulate processing
+	Processing *Processing `json:"processing,omitempty"`
+	// Broker statistics published under $SYS during the run
+	Sys *SysStats `json:"sys,omitempty"`
+	// Per-group message distribution, set by shared subscription runs
+	Shares []*ShareGroup `json:"shares,omitempty"`
+	Warmup *Summary      `json:"warmup,omitempty"` // Traffic excluded from the figures above
+}
+
+// Throughput returns successful operations per second over the run
+func (s *Summary) Throughput() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Succeeded) / s.Elapsed.Seconds()
+}
+
+// BytesThroughput returns successfully transferred payload bytes per second
+func (s *Summary) BytesThroughput() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Bytes) / s.Elapsed.Seconds()
+}
+
+// Add folds another run of the same benchmark, run after this one, into the
+// summary
+func (s *Summary) Add(other *Summary) {
+	s.merge(other)
+	s.Elapsed += other.Elapsed
+}
+
+// AddConcurrent folds a run of the same benchmark that ran alongside this one
+// into the summary. Their wall-clock spans overlap, so the elapsed time is the
+// longer of the two rather than the sum.
+func (s *Summary) AddConcurrent(other *Summary) {
+	s.merge(other)
+	s.Elapsed = max(s.Elapsed, other.Elapsed)
+}
+
+func (s *Summary) merge(other *Summary) {
+	if s.Benchmark == "" {
+		s.Benchmark = other.Benchmark
+	}
+	s.Clients += other.Clients
+	s.Expected += other.Expected
+	s.Succeeded += other.Succeeded
+	s.Failed += other.Failed
+	s.Bytes += other.Bytes
+	s.Topics = max(s.Topics, other.Topics)
+	s.TopicsCapped = s.TopicsCapped || other.TopicsCapped
+	s.Subscriptions += other.Subscriptions
+	if s.Arrival == "" {
+		s.Arrival = other.Arrival
+	}
+	if s.Payload == "" {
+		s.Payload = other.Payload
+	}
+}
+
+// Attrs returns the summary as log attributes
+func (s *Summary) Attrs() []slog.Attr {
+	attrs := []slog.Attr{
+		logger.String("benchmark", s.Benchmark),
+		logger.Int("clients", s.Clients),
+		logger.Any("expected", s.Expected),
+		logger.Any("succeeded", s.Succeeded),
+		logger.Any("failed", s.Failed),
+		logger.Float("elapsedSec", s.Elapsed.Seconds()),
+		logger.Float("throughputPerSec", s.Throughput()),
+		logger.Any("bytes", s.Bytes),
+		logger.Float("throughputBytesPerSec", s.BytesThroughput()),
+	}
+	if s.Topics > 0 {
+		attrs = append(attrs, logger.Int("distinctTopics", s.Topics))
+	}
+	if s.TopicsCapped {
+		attrs = append(attrs, logger.Bool("distinctTopicsLowerBound", true))
+	}
+	if s.Arrival != "" {
+		attrs = append(attrs, logger.String("arrival", s.Arrival))
+	}
+	if s.Payload != "" {
+		attrs = append(attrs, logger.String("payload", s.Payload))
+	}
+	if s.Subscriptions > 0 {
+		attrs = append(attrs, logger.Any("subscriptions", s.Subscriptions))
+	}
+	attrs = append(attrs, s.Latency.Attrs()...)
+	attrs = append(attrs, s.Suback.AttrsNamed("suback")...)
+	attrs = append(attrs, s.Verification.Attrs()...)
+	for _, seq := range s.Sequences {
+		attrs = append(attrs, seq.Attrs()...)
+	}
+	attrs = append(attrs, s.Pings.Attrs()...)
+	attrs = append(attrs, s.Sys.Attrs()...)
+	attrs = append(attrs, s.Integrity.Attrs()...)
+	attrs = append(attrs, s.Processing.Attrs()...)
+	attrs = append(attrs, s.Retained.Attrs()...)
+	attrs = append(attrs, s.Wills.Attrs()...)
+	attrs = append(attrs, s.Session.Attrs()...)
+	if s.Warmup != nil {
+		attrs = append(attrs,
+			logger.Any("warmupSucceeded", s.Warmup.Succeeded),
+			logger.Float("warmupElapsedSec", s.Warmup.Elapsed.Seconds()),
+		)
+	}
+	return attrs
+}
+
+// Attrs returns the latency distribution in milliseconds as log attributes
+func (l *Latency) Attrs() []slog.Attr {
+	return l.AttrsNamed("latency")
+}
+
+// AttrsNamed returns the distribution as log attributes prefixed with name
+func (l *Latency) AttrsNamed(name string) []slog.Attr {
+	if l == nil {
+		return nil
+	}
+	return []slog.Attr{
+		logger.Float(name+"MeanMs", ms(l.Mean)),
+		logger.Float(name+"P50Ms", ms(l.P50)),
+		logger.Float(name+"P90Ms", ms(l.P90)),
+		logger.Float(name+"P99Ms", ms(l.P99)),
+		logger.Float(name+"MaxMs", ms(l.Max)),
+	}
+}
+
+// ms converts a duration to fractional milliseconds
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,147 @@
+package bench
+
+import (
+	"cmp"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rayomqio/benchmq/internal/mqtt"
+	"github.com/rayomqio/benchmq/pkg/logger"
+)
+
+// sysFilter covers the statistics most brokers publish about themselves
+const sysFilter = "$SYS/#"
+
+// SysStats holds the broker statistics published under $SYS during a run
+type SysStats struct {
+	Updates int         `json:"updates"` // $SYS messages received
+	Topics  []*SysTopic `json:"topics"`
+}
+
+// SysTopic holds the values of a single $SYS topic over the run
+type SysTopic struct {
+	Topic   string      `json:"topic"`
+	Numeric bool        `json:"numeric"` // Every value parsed as a number
+	First   float64     `json:"first"`
+	Last    float64     `json:"last"`
+	Min     float64     `json:"min"`
+	Max     float64     `json:"max"`
+	Rate    float64     `json:"ratePerSec"` // Change from first to last sample per second, for counters
+	Samples []SysSample `json:"samples"`
+}
+
+// SysSample is a value published on a $SYS topic
+type SysSample struct {
+	At    time.Duration `json:"at"` // Offset from the start of the run
+	Value string        `json:"value"`
+}
+
+// Attrs returns the monitor totals as log attributes
+func (s *SysStats) Attrs() []slog.Attr {
+	if s == nil {
+		return nil
+	}
+	return []slog.Attr{
+		logger.Int("sysUpdates", s.Updates),
+		logger.Int("sysTopics", len(s.Topics)),
+	}
+}
+
+// Attrs returns the range of a numeric topic as log attributes
+func (t *SysTopic) Attrs() []slog.Attr {
+	return []slog.Attr{
+		logger.String("topic", t.Topic),
+		logger.Int("samples", len(t.Samples)),
+		logger.Float("first", t.First),
+		logger.Float("last", t.Last),
+		logger.Float("min", t.Min),
+		logger.Float("max", t.Max),
+		logger.Float("ratePerSec", t.Rate),
+	}
+}
+
+// sysMonitor records the $SYS values received by a dedicated client
+type sysMonitor struct {
+	client *mqtt.Adapter
+	start  time.Time
+	mu     sync.Mutex
+	topics map[string][]SysSample
+}
+
+// startSysMonitor connects the monitor client and subscribes to $SYS,
+// returning nil when the broker can't be monitored
+func (b *Bench) startSysMonitor() *sysMonitor {
+	cfg := *b.cfg
+	cfg.Client.ClientID = b.clientID + "-sys"
+	cfg.Client.CleanSession = true
+	cfg.Client.KeepAlive = b.keepAlive
+	cfg.Client.Username = b.username
+	cfg.Client.Password = b.password
+	m := &sysMonitor{
+		client: mqtt.NewClient(&cfg),
+		start:  time.Now(),
+		topics: make(map[string][]SysSample),
+	}
+
+	if err := m.client.Connect(); err != nil {
+		b.logger.Error("$SYS monitor connection failed", logger.ErrorAttr(err))
+		return nil
+	}
+	err := m.client.Subscribe(sysFilter, 0, false, func(msg mqtt.Message) {
+		m.mu.Lock()
+		m.topics[msg.Topic] = append(m.topics[msg.Topic], SysSample{
+			At:    msg.Received.Sub(m.start),
+			Value: string(msg.Payload),
+		})
+		m.mu.Unlock()
+	})
+	if err != nil {
+		m.client.Disconnect()
+		b.logger.Error("Failed to subscribe $SYS monitor", logger.ErrorAttr(err))
+		return nil
+	}
+	return m
+}
+
+// stop disconnects the monitor and returns the values in topic order
+func (m *sysMonitor) stop() *SysStats {
+	m.client.Disconnect()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := &SysStats{Topics: make([]*SysTopic, 0, len(m.topics))}
+	for topic, samples := range m.topics {
+		// Callbacks run concurrently, so samples are put back in arrival order
+		slices.SortStableFunc(samples, func(a, b SysSample) int { return cmp.Compare(a.At, b.At) })
+		stats.Updates += len(samples)
+		stats.Topics = append(stats.Topics, newSysTopic(topic, samples))
+	}
+	slices.SortFunc(stats.Topics, func(a, b *SysTopic) int { return strings.Compare(a.Topic, b.Topic) })
+	return stats
+}
+
+// newSysTopic summarizes the samples of a topic, ranging them when every value is a number
+func newSysTopic(topic string, samples []SysSample) *SysTopic {
+	t := &SysTopic{Topic: topic, Samples: samples, Numeric: true}
+	for i, s := range samples {
+		v, err := strconv.ParseFloat(strings.TrimSpace(s.Value), 64)
+		if err != nil {
+			return &SysTopic{Topic: topic, Samples: samples}
+		}
+		if i == 0 {
+			t.First, t.Min, t.Max = v, v, v
+		}
+		t.Last = v
+		t.Min = min(t.Min, v)
+		t.Max = max(t.Max, v)
+	}
+	if span := samples[len(samples)-1].At - samples[0].At; span > 0 {
+		t.Rate = (t.Last - t.First) / span.Seconds()
+	}
+	return t
+}
@@ -0,0 +1,114 @@
+package bench
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/rayomqio/benchmq/pkg/er"
+)
+
+// templateData holds the per-message variables available to templates
+type templateData struct {
+	ClientID    string    // Full client ID, e.g. benchmq-client-3
+	ClientIndex int       // Zero-based client index
+	Seq         int       // Zero-based message sequence of the client
+	Sub         int       // Zero-based subscription index of the client
+	Timestamp   int64     // Unix time in milliseconds
+	Time        time.Time // Render time
+}
+
+// renderer executes a template for a single client
+type renderer struct {
+	tmpl *template.Template
+	data templateData
+	out  bytes.Buffer
+}
+
+// newRenderer binds tmpl to the client with the given index and random source
+func newRenderer(tmpl *template.Template, rng *rand.Rand, index int, clientID string) *renderer {
+	return &renderer{
+		tmpl: bindTemplate(tmpl, rng),
+		data: templateData{ClientID: clientID, ClientIndex: index},
+	}
+}
+
+// render executes the template for the message with the given sequence.
+// The returned slice is only valid until the next call.
+func (r *renderer) render(seq int) ([]byte, error) {
+	now := time.Now()
+	r.data.Seq = seq
+	r.data.Timestamp = now.UnixMilli()
+	r.data.Time = now
+	r.out.Reset()
+	if err := r.tmpl.Execute(&r.out, &r.data); err != nil {
+		return nil, err
+	}
+	return r.out.Bytes(), nil
+}
+
+// isTemplate reports whether text contains template actions
+func isTemplate(text string) bool {
+	return strings.Contains(text, "{{")
+}
+
+// parseTemplate parses text once with placeholder functions. Clients bind
+// their own random source through bindTemplate before executing it.
+func parseTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Funcs(templateFuncs(nil)).Parse(text)
+	if err != nil {
+		return nil, &er.Error{
+			Package: "Bench",
+			Func:    "parseTemplate",
+			Message: er.ErrInvalidTemplate,
+			Raw:     err,
+		}
+	}
+	return tmpl, nil
+}
+
+// bindTemplate returns a copy of tmpl whose functions draw from rng, so
+// clients can render concurrently and reproducibly
+func bindTemplate(tmpl *template.Template, rng *rand.Rand) *template.Template {
+	return template.Must(tmpl.Clone()).Funcs(templateFuncs(rng))
+}
+
+// templateFuncs returns the template functions backed by rng
+func templateFuncs(rng *rand.Rand) template.FuncMap {
+	return template.FuncMap{
+		// rand returns an integer in [min, max]
+		"rand": func(lo, hi int) (int, error) {
+			if hi < lo {
+				return 0, fmt.Errorf("rand: max %d < min %d", hi, lo)
+			}
+			return lo + rng.IntN(hi-lo+1), nil
+		},
+		// randFloat returns a float in [min, max)
+		"randFloat": func(lo, hi float64) (float64, error) {
+			if hi < lo {
+				return 0, fmt.Errorf("randFloat: max %g < min %g", hi, lo)
+			}
+			return lo + rng.Float64()*(hi-lo), nil
+		},
+		// uuid returns a random version 4 UUID
+		"uuid": func() string {
+			var u [16]byte
+			for i := 0; i < 16; i += 8 {
+				v := rng.Uint64()
+				for j := 0; j < 8; j++ {
+					u[i+j] = byte(v >> (8 * j))
+				}
+			}
+			u[6] = (u[6] & 0x0f) | 0x40
+			u[8] = (u[8] & 0x3f) | 0x80
+			return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+		},
+		// now returns the current time in RFC 3339 format
+		"now": func() string {
+			return time.Now().Format(time.RFC3339Nano)
+		},
+	}
+}
@@ -0,0 +1,44 @@
+package bench
+
+import (
+	"math/rand/v2"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		check   func(string) bool
+		wantErr bool
+	}{
+		{"rand", `{{rand 3 5}}`, func(s string) bool { n, _ := strconv.Atoi(s); return n >= 3 && n <= 5 }, false},
+		{"rand single value", `{{rand 4 4}}`, func(s string) bool { return s == "4" }, false},
+		{"rand reversed", `{{rand 5 3}}`, nil, true},
+		{"randFloat", `{{randFloat 1.5 2.5}}`, func(s string) bool { f, _ := strconv.ParseFloat(s, 64); return f >= 1.5 && f < 2.5 }, false},
+		{"randFloat empty range", `{{randFloat 2.0 2.0}}`, func(s string) bool { return s == "2" }, false},
+		{"randFloat reversed", `{{randFloat 2.5 1.5}}`, nil, true},
+		{"uuid", `{{uuid}}`, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString, false},
+		{"data", `{{.ClientID}}/{{.ClientIndex}}/{{.Seq}}`, func(s string) bool { return s == "client-2/2/7" }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := parseTemplate(tt.name, tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := newRenderer(tmpl, rand.New(rand.NewPCG(1, 2)), 2, "client-2")
+			for range 100 {
+				out, err := r.render(7)
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("render %q error = %v, wantErr %v", tt.text, err, tt.wantErr)
+				}
+				if err == nil && !tt.check(string(out)) {
+					t.Fatalf("render %q = %q", tt.text, out)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package bench
+
+import (
+	"sync"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// topics yields the topics of a single client, rendering the topic template
+// when one is configured
+type topics struct {
+	static   string
+	renderer *renderer
+}
+
+// newTopics creates the topic source of the client with the given index
+func (b *Bench) newTopics(index int, clientID string) *topics {
+	t := &topics{static: b.topic}
+	if b.topicTemplate != nil {
+		rng := newRand(b.seed, streamTopics, index)
+		t.renderer = newRenderer(b.topicTemplate, rng, index, clientID)
+	}
+	return t
+}
+
+// next returns the topic of the message with the given sequence
+func (t *topics) next(seq int) (string, error) {
+	if t.renderer == nil {
+		return t.static, nil
+	}
+	topic, err := t.renderer.render(seq)
+	if err != nil {
+		return "", err
+	}
+	return string(topic), nil
+}
+
+// sub returns the k-th subscription topic of the client
+func (t *topics) sub(k int) (string, error) {
+	if t.renderer != nil {
+		t.renderer.data.Sub = k
+	}
+	return t.next(k)
+}
+
+// Distinct topic tracking stops growing past these sizes, after which the
+// count is a lower bound
+const (
+	maxClientTopics = 10_000
+	maxRunTopics    = 1_000_000
+)
+
+// clientTopics collects the distinct topics of a single client as hashes,
+// without locking
+type clientTopics struct {
+	seen   map[uint64]struct{}
+	capped bool
+}
+
+func (c *clientTopics) add(topic string) {
+	if c.seen == nil {
+		c.seen = make(map[uint64]struct{})
+	}
+	h := xxhash.Sum64String(topic)
+	if _, ok := c.seen[h]; ok {
+		return
+	}
+	if len(c.seen) >= maxClientTopics {
+		c.capped = true
+		return
+	}
+	c.seen[h] = struct{}{}
+}
+
+// topicSet counts the distinct topics used during a run, merged from the
+// clients as they finish
+type topicSet struct {
+	mu     sync.Mutex
+	seen   map[uint64]struct{}
+	capped bool
+}
+
+// merge folds the topics of a client into the run
+func (s *topicSet) merge(c *clientTopics) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.seen == nil {
+		s.seen = make(map[uint64]struct{})
+	}
+	s.capped = s.capped || c.capped
+	for h := range c.seen {
+		if _, ok := s.seen[h]; ok {
+			continue
+		}
+		if len(s.seen) >= maxRunTopics {
+			s.capped = true
+			return
+		}
+		s.seen[h] = struct{}{}
+	}
+}
+
+// count returns the distinct topics and whether tracking was capped, making
+// the count a lower bound
+func (s *topicSet) count() (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.seen), s.capped
+}
@@ -0,0 +1,307 @@
+package bench
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rayomqio/benchmq/internal/mqtt"
+	"github.com/rayomqio/benchmq/pkg/config"
+	"github.com/rayomqio/benchmq/pkg/er"
+	"github.com/rayomqio/benchmq/pkg/logger"
+)
+
+// Wills holds the outcome of killing clients and waiting for their wills
+type Wills struct {
+	Killed     int64 `json:"killed"`     // Clients whose connection was dropped
+	Monitors   int   `json:"monitors"`   // Clients subscribed to the will topics
+	Delivered  int64 `json:"delivered"`  // Distinct wills received, across monitors
+	Missing    int64 `json:"missing"`    // Wills of killed clients never received, across monitors
+	Duplicates int64 `json:"duplicates"` // Repeated deliveries of the same will
+	Premature  int64 `json:"premature"`  // Wills received before their client was killed
+	Cleared    int   `json:"cleared"`    // Retained will topics cleared afterwards
+}
+
+// Attrs returns the will results as log attributes
+func (w *Wills) Attrs() []slog.Attr {
+	if w == nil {
+		return nil
+	}
+	return []slog.Attr{
+		logger.Any("willKilled", w.Killed),
+		logger.Int("willMonitors", w.Monitors),
+		logger.Any("willDelivered", w.Delivered),
+		logger.Any("willMissing", w.Missing),
+		logger.Any("willDuplicates", w.Duplicates),
+		logger.Any("willPremature", w.Premature),
+		logger.Int("willCleared", w.Cleared),
+	}
+}
+
+// willTracker records the wills received by a single monitor
+type willTracker struct {
+	mu         sync.Mutex
+	seen       map[int]bool
+	duplicates int64
+	premature  int64
+	done       chan struct{} // Closed once the will of every killed client arrived
+}
+
+// WillDelivery connects clients with a will, drops their connections without
+// a DISCONNECT and measures how quickly and completely monitors receive the wills
+func (b *Bench) WillDelivery() *Summary {
+	start := time.Now()
+	b.logger.Info("Started will benchmark",
+		logger.String("start", start.Format(time.RFC3339Nano)),
+		logger.String("willTopic", b.willTopic),
+		logger.Int("willQoS", int(b.willQoS)),
+		logger.Bool("willRetain", b.willRetain),
+		logger.String("filter", b.willFilter),
+		logger.Int("monitors", b.willMonitors),
+	)
+
+	if b.willTopic == "" {
+		b.logger.Error("Will topic is not configured")
+		return &Summary{Benchmark: "will", Clients: b.clients, Failed: int64(b.clients)}
+	}
+
+	// Every will is identified by its topic and payload, distinct per client
+	wills := b.wills
+	owners := make(map[string]int, len(wills))
+	for i, w := range wills {
+		owners[willKey(w)] = i
+	}
+
+	var failed int64
+	var delivery latencies
+	killed := make([]atomic.Int64, b.clients) // Unix nanoseconds of each kill
+	var expected int64                        // Killed clients, set before the kills start
+	result := &Wills{Monitors: b.willMonitors}
+
+	// Monitors subscribe before any victim connects
+	monitors := make([]*mqtt.Adapter, 0, b.willMonitors)
+	trackers := make([]*willTracker, 0, b.willMonitors)
+	for m := 0; m < b.willMonitors; m++ {
+		id := fmt.Sprintf("%s-monitor-%d", b.clientID, m)
+		client := b.newWillClient(id, nil)
+		if err := client.Connect(); err != nil {
+			atomic.AddInt64(&failed, 1)
+			b.logger.Error("Monitor connection failed", logger.ClientID(id), logger.ErrorAttr(err))
+			continue
+		}
+		defer client.Disconnect()
+
+		t := &willTracker{seen: make(map[int]bool), done: make(chan struct{})}
+		err := client.Subscribe(b.willFilter, byte(b.qos), false, func(msg mqtt.Message) {
+			// Retained wills from earlier runs are not part of this one
+			if msg.Retained {
+				return
+			}
+			index, ok := owners[msg.Topic+"\x00"+string(msg.Payload)]
+			if !ok {
+				return
+			}
+
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			at := killed[index].Load()
+			switch {
+			case at == 0:
+				t.premature++
+			case t.seen[index]:
+				t.duplicates++
+			default:
+				t.seen[index] = true
+				delivery.record(msg.Received.Sub(time.Unix(0, at)))
+				if int64(len(t.seen)) == atomic.LoadInt64(&expected) {
+					close(t.done)
+				}
+			}
+		})
+		if err != nil {
+			atomic.AddInt64(&failed, 1)
+			b.logger.Error("Failed to subscribe monitor", logger.ClientID(id), logger.ErrorAttr(err))
+			continue
+		}
+		monitors = append(monitors, client)
+		trackers = append(trackers, t)
+	}
+
+	// Victims connect with their will set
+	victims := make([]*mqtt.Adapter, b.clients)
+	pool := b.newConnectPool()
+	for i := 0; i < b.clients; i++ {
+		b.wg.Add(1)
+		go func(index int) {
+			defer b.wg.Done()
+
+			id := fmt.Sprintf("%s-%d", b.clientID, index)
+			client := b.newWillClient(id, &wills[index])
+			if err := pool.connect(client); err != nil {
+				atomic.AddInt64(&failed, 1)
+				b.logger.Error("Client connection failed", logger.ClientID(id), logger.ErrorAttr(err))
+				return
+			}
+			victims[index] = client
+		}(i)
+	}
+	b.wg.Wait()
+
+	var connected int64
+	for _, v := range victims {
+		if v != nil {
+			connected++
+		}
+	}
+	atomic.StoreInt64(&expected, connected)
+
+	// Drop every connection without a DISCONNECT, spaced out by delay
+	killStart := time.Now()
+	for i, v := range victims {
+		if v == nil {
+			continue
+		}
+		killed[i].Store(time.Now().UnixNano())
+		if err := v.Kill(); err != nil {
+			b.logger.Error("Failed to kill client", logger.ClientID(fmt.Sprintf("%s-%d", b.clientID, i)), logger.ErrorAttr(err))
+		}
+		result.Killed++
+		if delay := b.meanDelay(); delay > 0 {
+			time.Sleep(delay)
+		}
+	}
+
+	timeout := time.After(b.willTimeout)
+	for _, t := range trackers {
+		if expected == 0 {
+			break
+		}
+		select {
+		case <-t.done:
+		case <-timeout:
+			b.logger.Warn("Timed out waiting for wills")
+		}
+	}
+	elapsed := time.Since(killStart)
+
+	for _, t := range trackers {
+		t.mu.Lock()
+		result.Delivered += int64(len(t.seen))
+		result.Missing += expected - int64(len(t.seen))
+		result.Duplicates += t.duplicates
+		result.Premature += t.premature
+		t.mu.Unlock()
+	}
+
+	if b.willRetain && len(monitors) > 0 {
+		result.Cleared = b.clearRetained(monitors[0], willTopics(wills))
+	}
+
+	summary := &Summary{
+		Benchmark: "will",
+		Clients:   b.clients,
+		Expected:  expected * int64(len(trackers)),
+		Succeeded: result.Delivered,
+		Failed:    failed,
+		Elapsed:   elapsed,
+		Latency:   delivery.summarize(),
+		Wills:     result,
+	}
+
+	attrs := []slog.Attr{
+		logger.Int("clients", b.clients),
+		logger.Any("expectedWills", summary.Expected),
+		logger.Any("failed", failed),
+		logger.Float("elapsedSec", summary.Elapsed.Seconds()),
+	}
+	attrs = append(attrs, result.Attrs()...)
+	attrs = append(attrs, summary.Latency.Attrs()...)
+	b.logger.Info("Finished will benchmark", attrs...)
+
+	return summary
+}
+
+// renderWills renders the will of every client, rejecting wills that share a
+// topic and payload since their deliveries couldn't be told apart
+func (b *Bench) renderWills() error {
+	b.wills = make([]config.Will, b.clients)
+	owners := make(map[string]int, b.clients)
+	for i := range b.wills {
+		w, err := b.renderWill(i, fmt.Sprintf("%s-%d", b.clientID, i))
+		if err != nil {
+			return &er.Error{
+				Package: "Bench",
+				Func:    "renderWills",
+				Message: er.ErrInvalidTemplate,
+				Raw:     err,
+			}
+		}
+		if other, ok := owners[willKey(w)]; ok {
+			return &er.Error{
+				Package: "Bench",
+				Func:    "renderWills",
+				Message: er.ErrDuplicateWill,
+				Raw:     fmt.Errorf("clients %d and %d both have will topic %q and payload %q", other, i, w.Topic, w.Payload),
+			}
+		}
+		owners[willKey(w)] = i
+		b.wills[i] = w
+	}
+	return nil
+}
+
+// willKey identifies a will by its topic and payload
+func willKey(w config.Will) string {
+	return w.Topic + "\x00" + w.Payload
+}
+
+// renderWill renders the will topic and payload of the client with the given index
+func (b *Bench) renderWill(index int, clientID string) (config.Will, error) {
+	w := config.Will{Topic: b.willTopic, Payload: b.willPayload, QoS: b.willQoS, Retain: b.willRetain}
+	rng := newRand(b.seed, streamWill, index)
+	if b.willTopicTemplate != nil {
+		topic, err := newRenderer(b.willTopicTemplate, rng, index, clientID).render(0)
+		if err != nil {
+			return w, err
+		}
+		w.Topic = string(topic)
+	}
+	if b.willPayloadTemplate != nil {
+		payload, err := newRenderer(b.willPayloadTemplate, rng, index, clientID).render(0)
+		if err != nil {
+			return w, err
+		}
+		w.Payload = string(payload)
+	}
+	return w, nil
+}
+
+// newWillClient creates a client with the given will, or none when will is nil
+func (b *Bench) newWillClient(id string, will *config.Will) *mqtt.Adapter {
+	cfg := *b.cfg
+	cfg.Client.ClientID = id
+	cfg.Client.CleanSession = true
+	cfg.Client.KeepAlive = b.keepAlive
+	cfg.Client.Username = b.username
+	cfg.Client.Password = b.password
+	cfg.Client.Will = config.Will{}
+	if will != nil {
+		cfg.Client.Will = *will
+	}
+	return mqtt.NewClient(&cfg)
+}
+
+// willTopics returns the distinct will topics
+func willTopics(wills []config.Will) []string {
+	seen := make(map[string]bool)
+	var topics []string
+	for _, w := range wills {
+		if !seen[w.Topic] {
+			seen[w.Topic] = true
+			topics = append(topics, w.Topic)
+		}
+	}
+	return topics
+}
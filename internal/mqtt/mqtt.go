@@ -1,8 +1,14 @@
 package mqtt
 
 import (
+	"context"
+	"crypto/tls"
 	"fmt"
+	"net"
+	"net/url"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	mq "github.com/eclipse/paho.mqtt.golang"
@@ -11,10 +17,34 @@ import (
 	"github.com/rayomqio/benchmq/pkg/logger"
 )
 
+// Message represents a message delivered to a subscription
+type Message struct {
+	Topic     string
+	Payload   []byte
+	QoS       byte
+	Retained  bool
+	Duplicate bool
+	Received  time.Time // Arrival time, taken before the callback is scheduled
+	Order     uint64    // Arrival order on the client, callbacks may run out of order
+}
+
 // Adapter represents an MQTT adapter instance
 type Adapter struct {
 	client mq.Client
 	wg     sync.WaitGroup
+	mu     sync.Mutex
+	conn   net.Conn // Current network connection, used by Kill
+	order  atomic.Uint64
+
+	sessionPresent bool         // Session present flag of the last CONNACK
+	blocking       bool         // Run subscription callbacks on the receive path
+	lost           atomic.Int64 // Connections lost since the client was created
+	timeouts       atomic.Int64 // Connections lost because no PINGRESP arrived in time
+	onPing         func(rtt time.Duration)
+	window         *publishWindow // Publishes in flight, see PublishAsync
+	windowMu       sync.Mutex     // Guards the reaper state against publishes racing a disconnect
+	reaping        bool
+	windowClosed   bool
 }
 
 // NewClient creates a new MQTT adapter instance
@@ -22,24 +52,75 @@ func NewClient(cfg *config.Config) *Adapter {
 	// Initialize MQTT client options
 	opts := mq.NewClientOptions()
 
-	opts.AddBroker(fmt.Sprintf("tcp://%s:%d", cfg.Server.Host, cfg.Server.Port))
+	scheme := "tcp"
+	var tlsCfg *tls.Config
+	var tlsErr error
+	if cfg.Server.TLS.Enabled {
+		scheme = "ssl"
+		tlsCfg, tlsErr = cfg.Server.TLS.Load(cfg.Server.Host)
+	}
+	opts.AddBroker(fmt.Sprintf("%s://%s:%d", scheme, cfg.Server.Host, cfg.Server.Port))
 	opts.SetClientID(cfg.Client.ClientID)
 	opts.SetKeepAlive(time.Duration(cfg.Client.KeepAlive) * time.Second)
 	opts.SetCleanSession(cfg.Client.CleanSession)
 	opts.SetUsername(cfg.Client.Username)
 	opts.SetPassword(cfg.Client.Password)
 	opts.SetProtocolVersion(4) // Default set to MQTT 3.1.1
+	if will := cfg.Client.Will; will.Topic != "" {
+		opts.SetWill(will.Topic, will.Payload, will.QoS, will.Retain)
+	}
+
+	a := &Adapter{}
+	opts.SetConnectionLostHandler(func(_ mq.Client, err error) {
+		a.lost.Add(1)
+		if err != nil && strings.Contains(err.Error(), "pingresp not received") {
+			a.timeouts.Add(1)
+		}
+	})
+
+	// Keep hold of the connection so Kill can drop it without a DISCONNECT
+	opts.SetCustomOpenConnectionFn(func(uri *url.URL, options mq.ClientOptions) (net.Conn, error) {
+		if tlsErr != nil {
+			return nil, tlsErr
+		}
+		var conn net.Conn
+		raw, err := net.DialTimeout("tcp", uri.Host, options.ConnectTimeout)
+		if err != nil {
+			return nil, err
+		}
+		conn = raw
+		if tlsCfg != nil {
+			tc := tls.Client(raw, tlsCfg)
+			ctx, cancel := context.WithTimeout(context.Background(), options.ConnectTimeout)
+			err := tc.HandshakeContext(ctx)
+			cancel()
+			if err != nil {
+				raw.Close()
+				return nil, err
+			}
+			conn = tc
+		}
+		a.mu.Lock()
+		a.conn = raw
+		onPing := a.onPing
+		a.mu.Unlock()
+		if onPing != nil {
+			return &pingConn{Conn: conn, onPing: onPing}, nil
+		}
+		return conn, nil
+	})
 
 	// Create a new MQTT client instance
-	client := mq.NewClient(opts)
+	a.client = mq.NewClient(opts)
 
 	// Return the initialized MQTT adapter
-	return &Adapter{client: client}
+	return a
 }
 
 // Connect establishes a connection to the MQTT broker
 func (a *Adapter) Connect() error {
-	if token := a.client.Connect(); token.Wait() && token.Error() != nil {
+	token := a.client.Connect()
+	if token.Wait() && token.Error() != nil {
 		tErr := token.Error()
 		return &er.Error{
 			Package: "MQTT",
@@ -48,9 +129,50 @@ func (a *Adapter) Connect() error {
 			Raw:     tErr,
 		}
 	}
+	if ct, ok := token.(*mq.ConnectToken); ok {
+		a.sessionPresent = ct.SessionPresent()
+	}
 	return nil
 }
 
+// SessionPresent reports whether the broker resumed a stored session on the last connect
+func (a *Adapter) SessionPresent() bool {
+	return a.sessionPresent
+}
+
+// ConnectionsLost returns how often the connection to the broker dropped unexpectedly
+func (a *Adapter) ConnectionsLost() int64 {
+	return a.lost.Load()
+}
+
+// KeepaliveTimeouts returns how often the connection dropped because the broker
+// didn't answer a PINGREQ within the keepalive interval
+func (a *Adapter) KeepaliveTimeouts() int64 {
+	return a.timeouts.Load()
+}
+
+// OnPing registers a function called with the round-trip time of every
+// PINGREQ answered by the broker. Call it before Connect.
+func (a *Adapter) OnPing(fn func(rtt time.Duration)) {
+	a.mu.Lock()
+	a.onPing = fn
+	a.mu.Unlock()
+}
+
+// SetBlocking runs subscription callbacks one at a time on the client's receive
+// path instead of in their own goroutines, so a slow callback holds back reading
+// from the broker the way a slow device would. Call it before subscribing.
+func (a *Adapter) SetBlocking(blocking bool) {
+	a.blocking = blocking
+}
+
+// Route registers callback for messages on topic without subscribing. Messages
+// queued in a persistent session arrive right after CONNACK, so their route
+// must be in place before Connect.
+func (a *Adapter) Route(topic string, callback func(msg Message)) {
+	a.client.AddRoute(topic, a.handler(callback))
+}
+
 // Publish publishes a message to the specified topic with the given QoS level and retention flag
 func (a *Adapter) Publish(topic string, qos byte, retained bool, payload any, callback func()) error {
 	if callback == nil {
@@ -102,6 +224,104 @@ func (a *Adapter) Publish(topic string, qos byte, retained bool, payload any, ca
 	return nil
 }
 
+// SetPublishWindow bounds the publishes PublishAsync keeps in flight and how
+// long each waits for its acknowledgement. It must be called before the first
+// PublishAsync, which otherwise waits for every acknowledgement.
+func (a *Adapter) SetPublishWindow(inflight int, timeout time.Duration) {
+	a.window = newPublishWindow(inflight, timeout)
+}
+
+// PublishAsync publishes a message without waiting for it to complete, blocking
+// only while the publish window is full. The callback runs once the broker
+// acknowledged the message, with the latency from publish to acknowledgement,
+// or with the error or timeout that ended it.
+func (a *Adapter) PublishAsync(topic string, qos byte, retained bool, payload any, callback func(latency time.Duration, err error)) error {
+	if callback == nil {
+		return &er.Error{
+			Package: "MQTT",
+			Func:    "PublishAsync",
+			Message: er.ErrNilCallback,
+		}
+	}
+
+	if err := a.Validate(topic, qos); err != nil {
+		return err
+	}
+
+	a.windowMu.Lock()
+	if a.windowClosed {
+		a.windowMu.Unlock()
+		return errDisconnected()
+	}
+	if !a.reaping {
+		if a.window == nil {
+			a.window = newPublishWindow(1, DefaultPublishTimeout)
+		}
+		a.reaping = true
+		a.wg.Add(1)
+		go func() {
+			defer a.wg.Done()
+			a.window.reap()
+		}()
+	}
+	w := a.window
+	a.windowMu.Unlock()
+
+	// Timed-out publishes keep their slots, so a window that paho never frees
+	// fails publishes rather than blocking the publisher
+	select {
+	case w.slots <- struct{}{}:
+	default:
+		if !a.client.IsConnectionOpen() {
+			return errDisconnected()
+		}
+		timer := time.NewTimer(w.timeout)
+		select {
+		case w.slots <- struct{}{}:
+			timer.Stop()
+		case <-timer.C:
+			return publishError(fmt.Errorf("publish window stayed full for %s", w.timeout))
+		}
+	}
+	// The reaper drains the pending queue right away, so a free slot finds room
+	a.windowMu.Lock()
+	defer a.windowMu.Unlock()
+	if a.windowClosed {
+		<-w.slots
+		return errDisconnected()
+	}
+	p := &pendingPublish{
+		sent:      time.Now(),
+		token:     a.client.Publish(topic, qos, retained, payload),
+		callback:  callback,
+		completed: make(chan struct{}),
+	}
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		w.watch(p)
+	}()
+	w.pending <- p
+
+	return nil
+}
+
+func errDisconnected() error {
+	return publishError(fmt.Errorf("client is disconnected"))
+}
+
+// closeWindow ends the publish reaper once the disconnect completed the
+// tokens, failing the publishes paho still holds
+func (a *Adapter) closeWindow() {
+	a.windowMu.Lock()
+	defer a.windowMu.Unlock()
+	if a.reaping && !a.windowClosed {
+		close(a.window.closed)
+		close(a.window.pending)
+	}
+	a.windowClosed = true
+}
+
 // Unsubscribe unsubscribes from the specified topic
 func (a *Adapter) Unsubscribe(topic string) error {
 	if err := a.Validate(topic, 0); err != nil {
@@ -133,7 +353,7 @@ func (a *Adapter) Unsubscribe(topic string) error {
 }
 
 // Subscribe subscribes to the specified topic with the given QoS level and retention flag
-func (a *Adapter) Subscribe(topic string, qos byte, retained bool, callback func(payload string)) error {
+func (a *Adapter) Subscribe(topic string, qos byte, retained bool, callback func(msg Message)) error {
 	if callback == nil {
 		return &er.Error{
 			Package: "MQTT",
@@ -146,22 +366,7 @@ func (a *Adapter) Subscribe(topic string, qos byte, retained bool, callback func
 		return err
 	}
 
-	token := a.client.Subscribe(topic, qos, func(client mq.Client, msg mq.Message) {
-		payload := string(msg.Payload())
-		a.wg.Add(1)
-		go func() {
-			defer a.wg.Done()
-			defer func() {
-				if r := recover(); r != nil {
-					logger.Error("panic in subscription callback",
-						logger.Any("recover", r),
-						logger.String("topic", topic),
-					)
-				}
-			}()
-			callback(payload)
-		}()
-	})
+	token := a.client.Subscribe(topic, qos, a.handler(callback))
 	token.Wait()
 
 	if !token.WaitTimeout(30 * time.Second) {
@@ -185,6 +390,85 @@ func (a *Adapter) Subscribe(topic string, qos byte, retained bool, callback func
 	return nil
 }
 
+// SubscribeMultiple subscribes to every topic with a single SUBSCRIBE packet,
+// taking the same QoS level and retention flag as Subscribe
+func (a *Adapter) SubscribeMultiple(topics []string, qos byte, retained bool, callback func(msg Message)) error {
+	if callback == nil {
+		return &er.Error{
+			Package: "MQTT",
+			Func:    "SubscribeMultiple",
+			Message: er.ErrNilCallback,
+		}
+	}
+
+	filters := make(map[string]byte, len(topics))
+	for _, topic := range topics {
+		if err := a.Validate(topic, qos); err != nil {
+			return err
+		}
+		filters[topic] = qos
+	}
+
+	token := a.client.SubscribeMultiple(filters, a.handler(callback))
+	token.Wait()
+
+	if !token.WaitTimeout(30 * time.Second) {
+		return &er.Error{
+			Package: "MQTT",
+			Func:    "SubscribeMultiple",
+			Message: er.ErrSubscribeFailed,
+			Raw:     fmt.Errorf("timeout waiting for subscribe token"),
+		}
+	}
+
+	if err := token.Error(); err != nil {
+		return &er.Error{
+			Package: "MQTT",
+			Func:    "SubscribeMultiple",
+			Message: er.ErrSubscribeFailed,
+			Raw:     err,
+		}
+	}
+
+	return nil
+}
+
+// handler wraps callback into a paho message handler that runs it in its own
+// goroutine, or inline when the adapter is blocking
+func (a *Adapter) handler(callback func(msg Message)) mq.MessageHandler {
+	return func(client mq.Client, msg mq.Message) {
+		m := Message{
+			Topic:     msg.Topic(),
+			Payload:   msg.Payload(),
+			QoS:       msg.Qos(),
+			Retained:  msg.Retained(),
+			Duplicate: msg.Duplicate(),
+			Received:  time.Now(),
+			Order:     a.order.Add(1),
+		}
+		run := func() {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Error("panic in subscription callback",
+						logger.Any("recover", r),
+						logger.String("topic", m.Topic),
+					)
+				}
+			}()
+			callback(m)
+		}
+		if a.blocking {
+			run()
+			return
+		}
+		a.wg.Add(1)
+		go func() {
+			defer a.wg.Done()
+			run()
+		}()
+	}
+}
+
 // Validate validates the topic and QoS level
 func (a *Adapter) Validate(topic string, qos byte) error {
 	if topic == "" {
@@ -205,8 +489,33 @@ func (a *Adapter) Validate(topic string, qos byte) error {
 	return nil
 }
 
+// Kill closes the network connection without sending DISCONNECT, so the broker
+// treats the client as lost and publishes its will. The client doesn't reconnect.
+func (a *Adapter) Kill() error {
+	a.mu.Lock()
+	conn := a.conn
+	a.mu.Unlock()
+	if conn == nil {
+		return &er.Error{
+			Package: "MQTT",
+			Func:    "Kill",
+			Message: er.ErrMqttConnectionFailed,
+			Raw:     fmt.Errorf("client is not connected"),
+		}
+	}
+
+	err := conn.Close()
+	// The DISCONNECT is written to the closed connection and never reaches the
+	// broker; this only stops the client from reconnecting
+	a.client.Disconnect(0)
+	a.closeWindow()
+	a.wg.Wait()
+	return err
+}
+
 // Disconnect disconnects the client from the MQTT broker
 func (a *Adapter) Disconnect() {
 	a.client.Disconnect(200)
+	a.closeWindow()
 	a.wg.Wait()
 }
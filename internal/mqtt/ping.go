@@ -0,0 +1,91 @@
+package mqtt
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// MQTT control packet types, from the high nibble of the fixed header
+const (
+	packetPingreq  = 12
+	packetPingresp = 13
+)
+
+// packetScanner follows MQTT packet boundaries in a byte stream that may be
+// split across reads or writes at any point
+type packetScanner struct {
+	header    bool // Fixed header byte seen, remaining length not complete yet
+	length    int  // Remaining length decoded so far
+	shift     uint
+	remaining int // Bytes left in the current packet's body
+}
+
+// scan consumes p and calls onPacket with the type of every packet that starts in it
+func (s *packetScanner) scan(p []byte, onPacket func(kind byte)) {
+	for len(p) > 0 {
+		switch {
+		case s.remaining > 0:
+			n := min(s.remaining, len(p))
+			s.remaining -= n
+			p = p[n:]
+		case s.header:
+			c := p[0]
+			p = p[1:]
+			s.length |= int(c&0x7f) << s.shift
+			s.shift += 7
+			if c&0x80 == 0 || s.shift > 21 {
+				s.header = false
+				s.remaining = s.length
+			}
+		default:
+			onPacket(p[0] >> 4)
+			p = p[1:]
+			s.header, s.length, s.shift = true, 0, 0
+		}
+	}
+}
+
+// pingConn measures the time between each PINGREQ written and the PINGRESP
+// read after it
+type pingConn struct {
+	net.Conn
+	onPing func(rtt time.Duration)
+
+	mu     sync.Mutex
+	out    packetScanner
+	in     packetScanner
+	sentAt time.Time // Zero while no PINGREQ is outstanding
+}
+
+func (c *pingConn) Write(p []byte) (int, error) {
+	now := time.Now()
+	c.mu.Lock()
+	c.out.scan(p, func(kind byte) {
+		if kind == packetPingreq {
+			c.sentAt = now
+		}
+	})
+	c.mu.Unlock()
+	return c.Conn.Write(p)
+}
+
+func (c *pingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if n > 0 {
+		now := time.Now()
+		var rtt time.Duration
+		c.mu.Lock()
+		c.in.scan(p[:n], func(kind byte) {
+			if kind == packetPingresp && !c.sentAt.IsZero() {
+				rtt = now.Sub(c.sentAt)
+				c.sentAt = time.Time{}
+			}
+		})
+		c.mu.Unlock()
+		if rtt > 0 {
+			c.onPing(rtt)
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,63 @@
+package mqtt
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestPacketScanner(t *testing.T) {
+	// A PUBLISH whose body is full of PINGREQ bytes, and one whose remaining
+	// length takes two bytes
+	body := bytes.Repeat([]byte{0xc0}, 200)
+	publish := append([]byte{0x30, 10}, body[:10]...)
+	long := append([]byte{0x32, 0xc8, 0x01}, body...)
+
+	tests := []struct {
+		name   string
+		stream [][]byte
+		want   []byte
+	}{
+		{"pingreq", [][]byte{{0xc0, 0x00}}, []byte{packetPingreq}},
+		{"pingresp", [][]byte{{0xd0, 0x00}}, []byte{packetPingresp}},
+		{"body bytes ignored", [][]byte{publish, {0xc0, 0x00}}, []byte{3, packetPingreq}},
+		{"two byte length", [][]byte{long, {0xd0, 0x00}}, []byte{3, packetPingresp}},
+		{"length split across writes", [][]byte{long[:2], long[2:], {0xd0, 0x00}}, []byte{3, packetPingresp}},
+		{"header split from length", [][]byte{{0xc0}, {0x00}, {0xd0}, {0x00}}, []byte{packetPingreq, packetPingresp}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s packetScanner
+			var got []byte
+			for _, chunk := range tt.stream {
+				s.scan(chunk, func(kind byte) { got = append(got, kind) })
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("packet types %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPacketScannerSplits(t *testing.T) {
+	body := bytes.Repeat([]byte{0xd0}, 300)
+	var stream []byte
+	stream = append(stream, 0xc0, 0x00)
+	stream = append(stream, 0x30, 0xac, 0x02)
+	stream = append(stream, body...)
+	stream = append(stream, 0xd0, 0x00)
+	want := []byte{packetPingreq, 3, packetPingresp}
+
+	for size := 1; size <= len(stream); size++ {
+		var s packetScanner
+		var got []byte
+		for p := stream; len(p) > 0; {
+			n := min(size, len(p))
+			s.scan(p[:n], func(kind byte) { got = append(got, kind) })
+			p = p[n:]
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("chunks of %d: packet types %v, want %v", size, got, want)
+		}
+	}
+}
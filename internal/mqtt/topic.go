@@ -0,0 +1,33 @@
+package mqtt
+
+import "strings"
+
+// MatchTopic reports whether topic matches the subscription filter following
+// the MQTT 3.1.1 wildcard rules. Wildcards at the first level never match
+// topics starting with '$'. Shared subscription filters ($share/<group>/...)
+// match like the filter that follows the group.
+func MatchTopic(filter, topic string) bool {
+	if rest, ok := strings.CutPrefix(filter, "$share/"); ok {
+		if _, f, ok := strings.Cut(rest, "/"); ok {
+			filter = f
+		}
+	}
+	if strings.HasPrefix(topic, "$") && (strings.HasPrefix(filter, "+") || strings.HasPrefix(filter, "#")) {
+		return false
+	}
+
+	f := strings.Split(filter, "/")
+	t := strings.Split(topic, "/")
+	for i, level := range f {
+		switch {
+		case level == "#":
+			// Matches the parent level and everything below it
+			return true
+		case i >= len(t):
+			return false
+		case level != "+" && level != t[i]:
+			return false
+		}
+	}
+	return len(f) == len(t)
+}
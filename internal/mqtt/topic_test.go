@@ -0,0 +1,42 @@
+package mqtt
+
+import "testing"
+
+func TestMatchTopic(t *testing.T) {
+	tests := []struct {
+		filter string
+		topic  string
+		want   bool
+	}{
+		{"bench/1", "bench/1", true},
+		{"bench/1", "bench/2", false},
+		{"bench/1", "bench/1/x", false},
+		{"bench/+", "bench/1", true},
+		{"bench/+", "bench/", true},
+		{"bench/+", "bench", false},
+		{"bench/+", "bench/1/x", false},
+		{"+/+", "/x", true},
+		{"bench/+/t", "bench/1/t", true},
+		{"bench/#", "bench", true},
+		{"bench/#", "bench/1/x", true},
+		{"bench/#", "other/1", false},
+		{"#", "bench/1", true},
+		{"#", "/bench", true},
+		{"#", "$SYS/broker/uptime", false},
+		{"+/broker/uptime", "$SYS/broker/uptime", false},
+		{"$SYS/#", "$SYS/broker/uptime", true},
+		{"$SYS/+/uptime", "$SYS/broker/uptime", true},
+		{"$share/g/bench/+", "bench/1", true},
+		{"$share/g/bench/+", "bench/1/x", false},
+		{"$share/g/#", "bench/1", true},
+		{"$share/g/#", "$SYS/broker", false},
+		{"$share/g/bench/1", "$share/g/bench/1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter+" "+tt.topic, func(t *testing.T) {
+			if got := MatchTopic(tt.filter, tt.topic); got != tt.want {
+				t.Errorf("MatchTopic(%q, %q) = %v, want %v", tt.filter, tt.topic, got, tt.want)
+			}
+		})
+	}
+}
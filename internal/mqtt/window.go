@@ -0,0 +1,129 @@
+package mqtt
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	mq "github.com/eclipse/paho.mqtt.golang"
+	"github.com/rayomqio/benchmq/pkg/er"
+)
+
+// DefaultPublishTimeout bounds how long a publish waits for its acknowledgement
+const DefaultPublishTimeout = 30 * time.Second
+
+// publishWindow pipelines the publishes of a client. It bounds the messages
+// paho holds at once; every publish is completed when its token is, and a
+// single reaper fails the ones that miss their deadline.
+type publishWindow struct {
+	slots   chan struct{}
+	pending chan *pendingPublish
+	closed  chan struct{}
+	timeout time.Duration
+}
+
+// pendingPublish is a publish waiting for its acknowledgement
+type pendingPublish struct {
+	token     mq.Token
+	sent      time.Time
+	callback  func(latency time.Duration, err error)
+	finished  atomic.Bool
+	completed chan struct{} // Closed once the watcher let go of the publish
+}
+
+func newPublishWindow(inflight int, timeout time.Duration) *publishWindow {
+	return &publishWindow{
+		slots:   make(chan struct{}, inflight),
+		pending: make(chan *pendingPublish, inflight),
+		closed:  make(chan struct{}),
+		timeout: timeout,
+	}
+}
+
+// finish runs the callback unless the publish already finished, so an ack
+// arriving after the timeout isn't counted twice
+func (p *pendingPublish) finish(latency time.Duration, err error) {
+	if p.finished.CompareAndSwap(false, true) {
+		p.callback(latency, err)
+	}
+}
+
+// watch completes p when paho completes its token, timing the ack as it
+// arrives. A publish keeps its slot until then, so the messages in flight
+// never exceed the window. Once the window is closed, a publish paho still
+// holds, such as one queued for a reconnect, fails instead.
+func (w *publishWindow) watch(p *pendingPublish) {
+	defer close(p.completed)
+	defer func() { <-w.slots }()
+
+	select {
+	case <-p.token.Done():
+	case <-w.closed:
+		select {
+		case <-p.token.Done():
+		default:
+			p.finish(0, publishError(fmt.Errorf("client disconnected before the acknowledgement")))
+			return
+		}
+	}
+	latency := time.Since(p.sent)
+	if err := p.token.Error(); err != nil {
+		p.finish(0, publishError(err))
+		return
+	}
+	p.finish(latency, nil)
+}
+
+// reap fails publishes in order as they pass their deadline, until the
+// window is closed and every publish was let go of. Publishes are taken off
+// the queue right away, so a slow head never holds up the publisher.
+func (w *publishWindow) reap() {
+	timer := time.NewTimer(w.timeout)
+	defer timer.Stop()
+
+	var queue []*pendingPublish
+	pending := w.pending
+	for pending != nil || len(queue) > 0 {
+		for len(queue) > 0 && isClosed(queue[0].completed) {
+			queue = queue[1:]
+		}
+
+		var completed <-chan struct{}
+		var expired <-chan time.Time
+		if len(queue) > 0 {
+			completed = queue[0].completed
+			timer.Reset(time.Until(queue[0].sent.Add(w.timeout)))
+			expired = timer.C
+		}
+		select {
+		case p, ok := <-pending:
+			if !ok {
+				pending = nil
+				continue
+			}
+			queue = append(queue, p)
+		case <-completed:
+		case <-expired:
+			queue[0].finish(0, publishError(fmt.Errorf("no acknowledgement within %s", w.timeout)))
+			queue = queue[1:]
+		}
+	}
+}
+
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func publishError(err error) error {
+	return &er.Error{
+		Package: "MQTT",
+		Func:    "PublishAsync",
+		Message: er.ErrPublishFailed,
+		Raw:     err,
+	}
+}
@@ -0,0 +1,273 @@
+package scenario
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/rayomqio/benchmq/internal/bench"
+	"github.com/rayomqio/benchmq/pkg/config"
+	"github.com/rayomqio/benchmq/pkg/logger"
+)
+
+// Report holds per-phase and per-group statistics of a scenario run
+type Report struct {
+	Scenario string        `json:"scenario"`
+	Elapsed  time.Duration `json:"elapsed"`
+	Phases   []PhaseReport `json:"phases"`
+	Groups   []GroupReport `json:"groups"` // Totals per group across all phases
+}
+
+// PhaseReport holds the statistics of a single phase
+type PhaseReport struct {
+	Name    string        `json:"name"`
+	Elapsed time.Duration `json:"elapsed"`
+	Groups  []GroupReport `json:"groups"`
+}
+
+// GroupReport holds the statistics of a client group
+type GroupReport struct {
+	Name    string         `json:"name"`
+	Role    Role           `json:"role"`
+	Summary *bench.Summary `json:"summary"`
+}
+
+// job binds a group to the benchmark built for it within a phase
+type job struct {
+	group Group
+	bench *bench.Bench
+}
+
+// Run executes the scenario against cfg and returns the combined report.
+// Options are applied to every group before the group's own settings.
+func Run(cfg *config.Config, s *Scenario, options ...bench.Option) (*Report, error) {
+	log := logger.NewBenchmarkLogger("Scenario")
+
+	// A group used by several phases gets per-phase client IDs, so parallel
+	// phases don't take over each other's connections
+	uses := make(map[string]int)
+	for _, p := range s.Phases {
+		for _, name := range p.Groups {
+			uses[name]++
+		}
+	}
+
+	// Build every benchmark up front so invalid groups fail before any traffic
+	jobs := make([][]job, len(s.Phases))
+	for i, p := range s.Phases {
+		for _, name := range p.Groups {
+			g, _ := s.Group(name)
+			if g.ClientID == "" {
+				g.ClientID = fmt.Sprintf("benchmq-%s", g.Name)
+			}
+			if uses[name] > 1 {
+				g.ClientID = fmt.Sprintf("%s-%s", g.ClientID, p.Name)
+			}
+			b, err := newGroupBench(cfg, s.groupSeed(name), g, options)
+			if err != nil {
+				return nil, err
+			}
+			jobs[i] = append(jobs[i], job{group: g, bench: b})
+		}
+	}
+
+	report := &Report{
+		Scenario: s.Name,
+		Phases:   make([]PhaseReport, len(s.Phases)),
+	}
+
+	start := time.Now()
+	log.Info("Started scenario", logger.String("scenario", s.Name), logger.Int("phases", len(s.Phases)))
+
+	// Consecutive phases marked parallel form a stage that runs concurrently
+	for i := 0; i < len(s.Phases); {
+		end := i + 1
+		for end < len(s.Phases) && s.Phases[end].Parallel {
+			end++
+		}
+
+		var wg sync.WaitGroup
+		for j := i; j < end; j++ {
+			wg.Add(1)
+			go func(idx int) {
+				defer wg.Done()
+				report.Phases[idx] = runPhase(log, s.Phases[idx], jobs[idx])
+			}(j)
+		}
+		wg.Wait()
+
+		i = end
+	}
+
+	report.Elapsed = time.Since(start)
+	report.Groups = groupTotals(s, report.Phases)
+	report.log(log)
+
+	return report, nil
+}
+
+// runPhase runs every group of the phase concurrently
+func runPhase(log *logger.Logger, p Phase, jobs []job) PhaseReport {
+	start := time.Now()
+	log.Info("Started phase", logger.String("phase", p.Name), logger.Any("groups", p.Groups))
+
+	pr := PhaseReport{
+		Name:   p.Name,
+		Groups: make([]GroupReport, len(jobs)),
+	}
+
+	var wg sync.WaitGroup
+	for i, j := range jobs {
+		wg.Add(1)
+		go func(idx int, j job) {
+			defer wg.Done()
+
+			var summary *bench.Summary
+			switch j.group.Role {
+			case RoleConn:
+				summary = j.bench.RunConnections()
+			case RolePub:
+				summary = j.bench.PublishMessages()
+			case RoleSub:
+				summary = j.bench.Subscribe()
+			}
+			pr.Groups[idx] = GroupReport{Name: j.group.Name, Role: j.group.Role, Summary: summary}
+		}(i, j)
+	}
+	wg.Wait()
+
+	pr.Elapsed = time.Since(start)
+	log.Info("Finished phase", logger.String("phase", p.Name), logger.Float("elapsedSec", pr.Elapsed.Seconds()))
+
+	return pr
+}
+
+// newGroupBench builds the benchmark for a group on its own copy of the config
+func newGroupBench(cfg *config.Config, seed int64, g Group, options []bench.Option) (*bench.Bench, error) {
+	c := *cfg
+
+	// Paced at sub-millisecond precision, so rates between whole delays hold
+	var interval time.Duration
+	if g.Rate > 0 {
+		interval = time.Duration(float64(time.Second) / g.Rate)
+	}
+
+	opts := []bench.Option{
+		bench.WithCleanSession(c.Client.CleanSession),
+		bench.WithKeepAlive(c.Client.KeepAlive),
+		bench.WithUsername(c.Client.Username),
+		bench.WithPassword(c.Client.Password),
+	}
+	opts = append(opts, options...)
+	opts = append(opts,
+		bench.WithClientID(g.ClientID),
+		bench.WithClients(g.Clients),
+		bench.WithDelay(0),
+		bench.WithInterval(interval),
+		bench.WithQoS(g.QoS),
+		bench.WithRetained(g.Retain),
+		bench.WithJitter(g.Jitter),
+		bench.WithSeed(seed),
+		bench.WithWarmup(g.Warmup),
+		bench.WithWarmupCount(g.WarmupCount),
+		bench.WithPayloadSize(g.PayloadSize),
+		bench.WithPayloadDir(g.PayloadDir),
+	)
+	if g.PayloadFile != "" {
+		format := bench.DefaultFormat
+		if g.PayloadFormat != "" {
+			format = bench.CorpusFormat(g.PayloadFormat)
+		}
+		opts = append(opts, bench.WithPayloadFile(g.PayloadFile, format))
+	}
+	if g.PayloadOrder != "" {
+		opts = append(opts, bench.WithPayloadOrder(bench.CorpusOrder(g.PayloadOrder)))
+	}
+	if g.PayloadContent != "" {
+		opts = append(opts, bench.WithPayloadContent(bench.PayloadContent(g.PayloadContent)))
+	}
+	if g.Arrival != "" {
+		opts = append(opts, bench.WithArrival(bench.Arrival(g.Arrival)))
+	}
+	if g.BurstSize > 0 {
+		opts = append(opts, bench.WithBurstSize(g.BurstSize))
+	}
+	if g.MaxInflight > 0 {
+		opts = append(opts, bench.WithMaxInflight(g.MaxInflight))
+	}
+	if g.Topic != "" {
+		opts = append(opts, bench.WithTopic(g.Topic))
+	}
+	if g.Payload != "" {
+		opts = append(opts, bench.WithMessage(g.Payload))
+	}
+	if g.Count > 0 {
+		opts = append(opts, bench.WithMessageCount(g.Count))
+	}
+
+	return bench.NewBenchmark(&c, opts...)
+}
+
+// groupTotals folds every phase result into one summary per group. Runs in
+// phases of the same stage overlap, so only the stages add up their time.
+func groupTotals(s *Scenario, phases []PhaseReport) []GroupReport {
+	totals := make([]GroupReport, 0, len(s.Groups))
+	for _, g := range s.Groups {
+		total := GroupReport{Name: g.Name, Role: g.Role, Summary: &bench.Summary{}}
+		ran := false
+		for i := 0; i < len(phases); {
+			end := i + 1
+			for end < len(phases) && s.Phases[end].Parallel {
+				end++
+			}
+
+			stage := &bench.Summary{}
+			inStage := false
+			for _, p := range phases[i:end] {
+				for _, gr := range p.Groups {
+					if gr.Name == g.Name && gr.Summary != nil {
+						stage.AddConcurrent(gr.Summary)
+						inStage = true
+					}
+				}
+			}
+			if inStage {
+				total.Summary.Add(stage)
+				ran = true
+			}
+
+			i = end
+		}
+		if ran {
+			totals = append(totals, total)
+		}
+	}
+	return totals
+}
+
+// log writes the report as structured log lines
+func (r *Report) log(log *logger.Logger) {
+	for _, p := range r.Phases {
+		for _, g := range p.Groups {
+			attrs := append([]slog.Attr{logger.String("phase", p.Name), logger.String("group", g.Name)}, g.Summary.Attrs()...)
+			log.Info("Phase group report", attrs...)
+		}
+		log.Info("Phase report",
+			logger.String("phase", p.Name),
+			logger.Int("groups", len(p.Groups)),
+			logger.Float("elapsedSec", p.Elapsed.Seconds()),
+		)
+	}
+
+	for _, g := range r.Groups {
+		attrs := append([]slog.Attr{logger.String("group", g.Name)}, g.Summary.Attrs()...)
+		log.Info("Group report", attrs...)
+	}
+
+	log.Info("Finished scenario",
+		logger.String("scenario", r.Scenario),
+		logger.Int("phases", len(r.Phases)),
+		logger.Float("elapsedSec", r.Elapsed.Seconds()),
+	)
+}
@@ -0,0 +1,67 @@
+package scenario
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rayomqio/benchmq/internal/bench"
+)
+
+func TestGroupTotals(t *testing.T) {
+	run := func(elapsed time.Duration, succeeded int64) GroupReport {
+		return GroupReport{Name: "pubs", Role: RolePub, Summary: &bench.Summary{Elapsed: elapsed, Succeeded: succeeded}}
+	}
+
+	tests := []struct {
+		name      string
+		phases    []Phase
+		reports   []PhaseReport
+		elapsed   time.Duration
+		succeeded int64
+	}{
+		{
+			"sequential phases add up",
+			[]Phase{{Name: "a"}, {Name: "b"}},
+			[]PhaseReport{{Groups: []GroupReport{run(time.Second, 10)}}, {Groups: []GroupReport{run(2*time.Second, 5)}}},
+			3 * time.Second, 15,
+		},
+		{
+			"parallel phases overlap",
+			[]Phase{{Name: "a"}, {Name: "b", Parallel: true}},
+			[]PhaseReport{{Groups: []GroupReport{run(time.Second, 10)}}, {Groups: []GroupReport{run(2*time.Second, 5)}}},
+			2 * time.Second, 15,
+		},
+		{
+			"parallel stage then sequential phase",
+			[]Phase{{Name: "a"}, {Name: "b", Parallel: true}, {Name: "c"}},
+			[]PhaseReport{
+				{Groups: []GroupReport{run(time.Second, 10)}},
+				{Groups: []GroupReport{run(time.Second, 10)}},
+				{Groups: []GroupReport{run(time.Second, 10)}},
+			},
+			2 * time.Second, 30,
+		},
+		{
+			"stage without the group",
+			[]Phase{{Name: "a"}, {Name: "b"}},
+			[]PhaseReport{{Groups: []GroupReport{run(time.Second, 10)}}, {}},
+			time.Second, 10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Scenario{
+				Groups: []Group{{Name: "pubs", Role: RolePub}, {Name: "idle", Role: RoleSub}},
+				Phases: tt.phases,
+			}
+			totals := groupTotals(s, tt.reports)
+			if len(totals) != 1 || totals[0].Name != "pubs" {
+				t.Fatalf("totals = %+v, want only the group that ran", totals)
+			}
+			got := totals[0].Summary
+			if got.Elapsed != tt.elapsed || got.Succeeded != tt.succeeded {
+				t.Errorf("total elapsed %s succeeded %d, want %s and %d", got.Elapsed, got.Succeeded, tt.elapsed, tt.succeeded)
+			}
+		})
+	}
+}
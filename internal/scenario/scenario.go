@@ -0,0 +1,215 @@
+package scenario
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rayomqio/benchmq/pkg/er"
+	"gopkg.in/yaml.v3"
+)
+
+// Role names the benchmark a client group runs
+type Role string
+
+const (
+	RoleConn Role = "conn" // Connect and disconnect
+	RolePub  Role = "pub"  // Publish messages
+	RoleSub  Role = "sub"  // Subscribe and count messages
+)
+
+// Scenario represents the entire yaml scenario file fields
+type Scenario struct {
+	Name   string  `yaml:"name"`
+	Seed   int64   `yaml:"seed"`
+	Groups []Group `yaml:"groups"`
+	Phases []Phase `yaml:"phases"`
+}
+
+// Group represents a set of identical clients sharing one role
+type Group struct {
+	Name     string  `yaml:"name"`
+	Role     Role    `yaml:"role"`
+	Clients  int     `yaml:"clients"` // Required
+	ClientID string  `yaml:"client_id"`
+	Topic    string  `yaml:"topic"`
+	QoS      uint16  `yaml:"qos"`
+	Payload  string  `yaml:"payload"`
+	Count    int     `yaml:"count"`
+	Rate     float64 `yaml:"rate"` // Messages per second per client, up to 1000
+	Retain   bool    `yaml:"retain"`
+	// Generated payloads, see bench.ParsePayloadSize
+	PayloadSize    string `yaml:"payload_size"`
+	PayloadContent string `yaml:"payload_content"`
+	// Replayed payload corpus, see bench.WithPayloadFile
+	PayloadFile   string `yaml:"payload_file"`
+	PayloadFormat string `yaml:"payload_format"`
+	PayloadDir    string `yaml:"payload_dir"`
+	PayloadOrder  string `yaml:"payload_order"`
+	// Publish arrival pattern, see bench.Arrival
+	Arrival   string `yaml:"arrival"`
+	Jitter    int    `yaml:"jitter"`
+	BurstSize int    `yaml:"burst_size"`
+	// Unacknowledged publishes per client
+	MaxInflight int `yaml:"max_inflight"`
+	// Warm-up excluded from the group statistics
+	Warmup      time.Duration `yaml:"warmup"`
+	WarmupCount int           `yaml:"warmup_count"`
+}
+
+// Phase represents a step of the scenario running one or more groups concurrently
+type Phase struct {
+	Name   string   `yaml:"name"`
+	Groups []string `yaml:"groups"`
+	// Parallel starts the phase together with the previous one instead of after it
+	Parallel bool `yaml:"parallel"`
+}
+
+// Load reads and strictly decodes the scenario file at path
+func Load(path string) (*Scenario, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &er.Error{
+			Package: "Scenario",
+			Func:    "Load",
+			Message: er.ErrScenarioReadFailed,
+			Raw:     err,
+		}
+	}
+
+	var s Scenario
+	dec := yaml.NewDecoder(bytes.NewReader(raw))
+	dec.KnownFields(true)
+	if err := dec.Decode(&s); err != nil {
+		return nil, &er.Error{
+			Package: "Scenario",
+			Func:    "Load",
+			Message: er.ErrUnmarshalFailed,
+			Raw:     err,
+		}
+	}
+
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// Validate checks that groups are well formed and phases reference known groups
+func (s *Scenario) Validate() error {
+	if len(s.Groups) == 0 {
+		return &er.Error{
+			Package: "Scenario",
+			Func:    "Validate",
+			Message: er.ErrScenarioNoGroups,
+		}
+	}
+	if len(s.Phases) == 0 {
+		return &er.Error{
+			Package: "Scenario",
+			Func:    "Validate",
+			Message: er.ErrScenarioNoPhases,
+		}
+	}
+
+	groups := make(map[string]struct{}, len(s.Groups))
+	for _, g := range s.Groups {
+		if g.Name == "" {
+			return &er.Error{
+				Package: "Scenario",
+				Func:    "Validate",
+				Message: er.ErrScenarioInvalidGroup,
+				Raw:     fmt.Errorf("group name cannot be empty"),
+			}
+		}
+		if _, ok := groups[g.Name]; ok {
+			return &er.Error{
+				Package: "Scenario",
+				Func:    "Validate",
+				Message: er.ErrScenarioInvalidGroup,
+				Raw:     fmt.Errorf("duplicate group %q", g.Name),
+			}
+		}
+		switch g.Role {
+		case RoleConn, RolePub, RoleSub:
+		default:
+			return &er.Error{
+				Package: "Scenario",
+				Func:    "Validate",
+				Message: er.ErrScenarioInvalidGroup,
+				Raw:     fmt.Errorf("group %q has unknown role %q", g.Name, g.Role),
+			}
+		}
+		if g.Clients <= 0 {
+			return &er.Error{
+				Package: "Scenario",
+				Func:    "Validate",
+				Message: er.ErrScenarioInvalidGroup,
+				Raw:     fmt.Errorf("group %q needs clients > 0", g.Name),
+			}
+		}
+		// Publishers sleep between messages, which can't keep up with faster rates
+		if g.Rate < 0 || g.Rate > 1000 {
+			return &er.Error{
+				Package: "Scenario",
+				Func:    "Validate",
+				Message: er.ErrScenarioInvalidGroup,
+				Raw:     fmt.Errorf("group %q rate must be between 0 and 1000", g.Name),
+			}
+		}
+		groups[g.Name] = struct{}{}
+	}
+
+	for i, p := range s.Phases {
+		if len(p.Groups) == 0 {
+			return &er.Error{
+				Package: "Scenario",
+				Func:    "Validate",
+				Message: er.ErrScenarioInvalidPhase,
+				Raw:     fmt.Errorf("phase %q has no groups", p.Name),
+			}
+		}
+		for _, name := range p.Groups {
+			if _, ok := groups[name]; !ok {
+				return &er.Error{
+					Package: "Scenario",
+					Func:    "Validate",
+					Message: er.ErrScenarioInvalidPhase,
+					Raw:     fmt.Errorf("phase %q references unknown group %q", p.Name, name),
+				}
+			}
+		}
+		if p.Name == "" {
+			s.Phases[i].Name = fmt.Sprintf("phase-%d", i+1)
+		}
+	}
+
+	return nil
+}
+
+// groupSeed derives the seed of a group from the scenario seed and the group's
+// position, so groups don't draw identical arrival and payload streams. A zero
+// scenario seed stays zero and lets every group pick its own.
+func (s *Scenario) groupSeed(name string) int64 {
+	if s.Seed == 0 {
+		return 0
+	}
+	for i, g := range s.Groups {
+		if g.Name == name {
+			return s.Seed + int64(i)
+		}
+	}
+	return s.Seed
+}
+
+// Group returns the group with the given name
+func (s *Scenario) Group(name string) (Group, bool) {
+	for _, g := range s.Groups {
+		if g.Name == name {
+			return g, true
+		}
+	}
+	return Group{}, false
+}
@@ -2,25 +2,58 @@ package config
 
 import (
 	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/rayomqio/benchmq/pkg/er"
+	"github.com/rayomqio/benchmq/pkg/redact"
 	"gopkg.in/yaml.v3"
 )
 
 // Config represents the entire yaml config file fields
 type Config struct {
-	Name        string `yaml:"name"`
-	Version     string `yaml:"version"`
-	Environment string `yaml:"environment"`
-	Server      server `yaml:"server"`
-	Client      Client `yaml:"client"`
+	Name        string    `yaml:"name"`
+	Version     string    `yaml:"version"`
+	Environment string    `yaml:"environment"`
+	Server      server    `yaml:"server"`
+	Client      Client    `yaml:"client"`
+	Conn        Benchmark `yaml:"conn"`
+	Pub         Benchmark `yaml:"pub"`
+	Sub         Benchmark `yaml:"sub"`
+	// Named broker profiles, one of which replaces server and credentials
+	Brokers map[string]Broker `yaml:"brokers"`
 }
 
 // Server represents the server configuration fields
 type server struct {
 	Host string `yaml:"host"`
 	Port uint16 `yaml:"port"`
+	TLS  TLS    `yaml:"tls"`
+}
+
+// TLS represents the TLS settings of a broker connection
+type TLS struct {
+	Enabled            bool   `yaml:"enabled"`
+	CAFile             string `yaml:"ca_file"`   // PEM bundle trusted instead of the system roots
+	CertFile           string `yaml:"cert_file"` // Client certificate, requires key_file
+	KeyFile            string `yaml:"key_file"`
+	ServerName         string `yaml:"server_name"` // Defaults to the broker host
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+}
+
+// Broker represents a named broker profile selected with --broker. Empty
+// credentials keep the client section's.
+type Broker struct {
+	Host         string `yaml:"host"`
+	Port         uint16 `yaml:"port"`
+	TLS          TLS    `yaml:"tls"`
+	Username     string `yaml:"username"`
+	Password     string `yaml:"password"`
+	PasswordFile string `yaml:"password_file"`
+	PasswordEnv  string `yaml:"password_env"`
 }
 
 // Client represents the client configuration fields
@@ -30,46 +63,215 @@ type Client struct {
 	CleanSession bool   `yaml:"clean_session"`
 	Username     string `yaml:"username"`
 	Password     string `yaml:"password"`
+	PasswordFile string `yaml:"password_file"` // Read the password from this file instead
+	PasswordEnv  string `yaml:"password_env"`  // Read the password from this environment variable instead
+	Will         Will   `yaml:"will"`
+}
+
+// Will represents the last will and testament fields, disabled when Topic is empty
+type Will struct {
+	Topic   string `yaml:"topic"`
+	Payload string `yaml:"payload"`
+	QoS     byte   `yaml:"qos"`
+	Retain  bool   `yaml:"retain"`
+}
+
+// FileName is the config file looked up in every search directory
+const FileName = "config.yml"
+
+// DefaultClientID is the client ID used when neither the file nor the
+// environment sets one
+const DefaultClientID = "benchmq-client"
+
+// SearchPaths returns the directories searched for the config file, in order:
+// the working directory, $XDG_CONFIG_HOME/benchmq (~/.config/benchmq when
+// unset) and /etc/benchmq
+func SearchPaths() []string {
+	paths := []string{"."}
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		paths = append(paths, filepath.Join(xdg, "benchmq"))
+	} else if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".config", "benchmq"))
+	}
+	return append(paths, "/etc/benchmq")
+}
+
+// Benchmark represents the default parameters of a benchmark command. Nil
+// fields are unset and leave the command's flag defaults in place, so an
+// explicit zero or false still applies.
+type Benchmark struct {
+	Clients *int    `yaml:"clients"`
+	Delay   *int    `yaml:"delay"` // Milliseconds
+	QoS     *uint16 `yaml:"qos"`
+	Topic   *string `yaml:"topic"`
+	Message *string `yaml:"message"`
+	Count   *int    `yaml:"count"`
+	Retain  *bool   `yaml:"retain"`
 }
 
-// InitializeCfg reads the config file and returns a pointer to the Config struct
-// If config.yml doesn't exist, it returns a config with default values
+// InitializeCfg loads the config from the search paths, see Load
 func InitializeCfg() (*Config, error) {
+	return Load("", "")
+}
+
+// Load reads the config file at path, or the first config.yml found in the
+// search paths when path is empty. BENCHMQ_* environment variables override
+// the file, and defaults fill whatever neither of them set. A missing file is
+// only an error when path was given explicitly. A non-empty broker selects
+// the broker profile of that name, which takes precedence over the
+// environment.
+func Load(path, broker string) (*Config, error) {
 	var cfg Config
-	configFileExists := false
 
-	// Try to read config file, but don't fail if it doesn't exist
-	rawCfg, err := os.ReadFile("config.yml")
-	if err == nil {
-		configFileExists = true
-		// Config file exists, parse it
+	rawCfg, found, err := readConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	if found {
 		dec := yaml.NewDecoder(bytes.NewReader(rawCfg))
 		dec.KnownFields(true)
 		if err = dec.Decode(&cfg); err != nil {
 			return nil, &er.Error{
 				Package: "Config",
-				Func:    "InitializeCfg",
+				Func:    "Load",
 				Message: er.ErrUnmarshalFailed,
+				Raw:     err,
 			}
 		}
-	} else if !os.IsNotExist(err) {
-		// File exists but we can't read it (permissions, etc.)
-		return nil, &er.Error{
-			Package: "Config",
-			Func:    "InitializeCfg",
-			Message: er.ErrConfigReadFailed,
-		}
 	}
-	// If config file doesn't exist, cfg remains with zero values which will be filled by SetDefaults()
+	// Without a config file, cfg keeps zero values which SetDefaults fills
 
-	cfg.SetDefaults(configFileExists)
+	cfg.SetDefaults(found)
+	if err := cfg.ApplyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	// The profile is chosen with a flag, so it wins over the environment
+	if broker != "" {
+		if err := cfg.UseBroker(broker); err != nil {
+			return nil, err
+		}
+	}
+	// Whether TLS is on is only settled once the environment and profile applied
+	if cfg.Server.Port == 0 {
+		cfg.Server.Port = defaultPort(cfg.Server.TLS.Enabled)
+	}
 	if err := cfg.Validate(); err != nil {
 		return nil, err
 	}
+	if err := cfg.Client.resolvePassword(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	redact.Register(cfg.Client.Password)
+	for _, b := range cfg.Brokers {
+		redact.Register(b.Password)
+	}
 
 	return &cfg, nil
 }
 
+// readConfig returns the content of the config file and whether one was found
+func readConfig(path string) ([]byte, bool, error) {
+	if path != "" {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, false, &er.Error{
+				Package: "Config",
+				Func:    "Load",
+				Message: er.ErrConfigReadFailed,
+				Raw:     err,
+			}
+		}
+		return raw, true, nil
+	}
+
+	for _, dir := range SearchPaths() {
+		raw, err := os.ReadFile(filepath.Join(dir, FileName))
+		if err == nil {
+			return raw, true, nil
+		}
+		if !os.IsNotExist(err) {
+			// File exists but we can't read it (permissions, etc.)
+			return nil, false, &er.Error{
+				Package: "Config",
+				Func:    "Load",
+				Message: er.ErrConfigReadFailed,
+				Raw:     err,
+			}
+		}
+	}
+	return nil, false, nil
+}
+
+// UseBroker replaces the server and, when the profile has them, the
+// credentials with the named broker profile. A profile without a port gets
+// the default of Load, 8883 with TLS and 1883 otherwise.
+func (c *Config) UseBroker(name string) error {
+	b, ok := c.Brokers[name]
+	if !ok {
+		return &er.Error{
+			Package: "Config",
+			Func:    "UseBroker",
+			Message: er.ErrUnknownBroker,
+			Raw:     fmt.Errorf("broker %q", name),
+		}
+	}
+	c.Server = server{Host: b.Host, Port: b.Port, TLS: b.TLS}
+	if b.Username != "" || b.Password != "" || b.PasswordFile != "" || b.PasswordEnv != "" {
+		c.Client.Username = b.Username
+		c.Client.Password = b.Password
+		c.Client.PasswordFile = b.PasswordFile
+		c.Client.PasswordEnv = b.PasswordEnv
+	}
+	return nil
+}
+
+// defaultPort returns the standard MQTT port, 8883 when TLS is enabled
+func defaultPort(tls bool) uint16 {
+	if tls {
+		return 8883
+	}
+	return 1883
+}
+
+// Load builds the TLS configuration for connecting to host
+func (t TLS) Load(host string) (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+	if cfg.ServerName == "" {
+		cfg.ServerName = host
+	}
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %s", t.CAFile)
+		}
+	}
+	if t.CertFile != "" || t.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// validate checks that the TLS files of an enabled configuration load
+func (t TLS) validate(host string) error {
+	if !t.Enabled {
+		return nil
+	}
+	_, err := t.Load(host)
+	return err
+}
+
 // Validate does the validation over server configuration
 func (c *Config) Validate() error {
 	if c.Server.Host == "" {
@@ -86,6 +288,70 @@ func (c *Config) Validate() error {
 			Message: er.ErrInvalidServerPort,
 		}
 	}
+	if c.Client.Will.QoS > 2 || (c.Client.Will.Topic == "" && (c.Client.Will.Payload != "" || c.Client.Will.Retain)) {
+		return &er.Error{
+			Package: "Config",
+			Func:    "Validate",
+			Message: er.ErrInvalidWill,
+		}
+	}
+	for _, section := range []struct {
+		name string
+		b    Benchmark
+	}{{"conn", c.Conn}, {"pub", c.Pub}, {"sub", c.Sub}} {
+		b := section.b
+		if (b.Clients != nil && *b.Clients <= 0) || (b.Delay != nil && *b.Delay < 0) ||
+			(b.Count != nil && *b.Count < 0) || (b.QoS != nil && *b.QoS > 2) {
+			return &er.Error{
+				Package: "Config",
+				Func:    "Validate",
+				Message: er.ErrInvalidBenchmarkConfig,
+				Raw:     fmt.Errorf("%s section", section.name),
+			}
+		}
+	}
+	if n := countSources(c.Client.Password, c.Client.PasswordFile, c.Client.PasswordEnv); n > 1 {
+		return &er.Error{
+			Package: "Config",
+			Func:    "Validate",
+			Message: er.ErrConflictingPassword,
+			Raw:     fmt.Errorf("client sets %d password sources", n),
+		}
+	}
+	if err := c.Server.TLS.validate(c.Server.Host); err != nil {
+		return &er.Error{
+			Package: "Config",
+			Func:    "Validate",
+			Message: er.ErrInvalidTLS,
+			Raw:     err,
+		}
+	}
+	for name, b := range c.Brokers {
+		if b.Host == "" {
+			return &er.Error{
+				Package: "Config",
+				Func:    "Validate",
+				Message: er.ErrInvalidBroker,
+				Raw:     fmt.Errorf("broker %q has no host", name),
+			}
+		}
+		if countSources(b.Password, b.PasswordFile, b.PasswordEnv) > 1 {
+			return &er.Error{
+				Package: "Config",
+				Func:    "Validate",
+				Message: er.ErrInvalidBroker,
+				Raw:     fmt.Errorf("broker %q: %w", name, er.ErrConflictingPassword),
+			}
+		}
+		if err := b.TLS.validate(b.Host); err != nil {
+			return &er.Error{
+				Package: "Config",
+				Func:    "Validate",
+				Message: er.ErrInvalidBroker,
+				Raw:     fmt.Errorf("broker %q: %w", name, err),
+			}
+		}
+	}
 	return nil
 }
 
@@ -104,11 +370,8 @@ func (c *Config) SetDefaults(configFileExists bool) {
 	if c.Server.Host == "" {
 		c.Server.Host = "localhost"
 	}
-	if c.Server.Port == 0 {
-		c.Server.Port = 1883
-	}
 	if c.Client.ClientID == "" {
-		c.Client.ClientID = "benchmq-client"
+		c.Client.ClientID = DefaultClientID
 	}
 	if c.Client.KeepAlive == 0 {
 		c.Client.KeepAlive = 60
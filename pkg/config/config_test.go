@@ -0,0 +1,41 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadPortDefault(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	raw := "server:\n  host: localhost\nbrokers:\n  secure:\n    host: broker\n    tls:\n      enabled: true\n"
+	if err := os.WriteFile(path, []byte(raw), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		env    map[string]string
+		broker string
+		want   uint16
+	}{
+		{"plain", nil, "", 1883},
+		{"tls from env", map[string]string{"BENCHMQ_SERVER_TLS_ENABLED": "true"}, "", 8883},
+		{"port from env", map[string]string{"BENCHMQ_SERVER_TLS_ENABLED": "true", "BENCHMQ_SERVER_PORT": "9000"}, "", 9000},
+		{"tls profile", nil, "secure", 8883},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			cfg, err := Load(path, tt.broker)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Server.Port != tt.want {
+				t.Errorf("port = %d, want %d", cfg.Server.Port, tt.want)
+			}
+		})
+	}
+}
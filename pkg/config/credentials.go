@@ -0,0 +1,95 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rayomqio/benchmq/pkg/er"
+	"golang.org/x/term"
+)
+
+// resolvePassword replaces the password with the one read from password_file
+// or password_env, whichever is set
+func (c *Client) resolvePassword(lookup func(string) (string, bool)) error {
+	var err error
+	switch {
+	case c.PasswordFile != "":
+		c.Password, err = PasswordFromFile(c.PasswordFile)
+	case c.PasswordEnv != "":
+		c.Password, err = passwordFromEnv(c.PasswordEnv, lookup)
+	}
+	return err
+}
+
+// PasswordFromFile reads a password from the first line of a file, such as a
+// mounted secret
+func PasswordFromFile(path string) (string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return "", &er.Error{
+			Package: "Config",
+			Func:    "PasswordFromFile",
+			Message: er.ErrPasswordSource,
+			Raw:     err,
+		}
+	}
+	line, _, _ := strings.Cut(string(raw), "\n")
+	return strings.TrimSuffix(line, "\r"), nil
+}
+
+// PasswordFromEnv reads a password from the named environment variable, which
+// must be set
+func PasswordFromEnv(name string) (string, error) {
+	return passwordFromEnv(name, os.LookupEnv)
+}
+
+func passwordFromEnv(name string, lookup func(string) (string, bool)) (string, error) {
+	password, ok := lookup(name)
+	if !ok {
+		return "", &er.Error{
+			Package: "Config",
+			Func:    "PasswordFromEnv",
+			Message: er.ErrPasswordSource,
+			Raw:     fmt.Errorf("environment variable %s is not set", name),
+		}
+	}
+	return password, nil
+}
+
+// PromptPassword asks for a password on the terminal without echoing it. The
+// prompt goes to stderr so it stays out of redirected output.
+func PromptPassword(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", &er.Error{
+			Package: "Config",
+			Func:    "PromptPassword",
+			Message: er.ErrPasswordSource,
+			Raw:     fmt.Errorf("stdin is not a terminal"),
+		}
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	raw, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", &er.Error{
+			Package: "Config",
+			Func:    "PromptPassword",
+			Message: er.ErrPasswordSource,
+			Raw:     err,
+		}
+	}
+	return string(raw), nil
+}
+
+// countSources returns how many of the given password sources are set
+func countSources(sources ...string) int {
+	n := 0
+	for _, s := range sources {
+		if s != "" {
+			n++
+		}
+	}
+	return n
+}
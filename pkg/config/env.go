@@ -0,0 +1,114 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rayomqio/benchmq/pkg/er"
+)
+
+// EnvPrefix starts the name of every environment variable override
+const EnvPrefix = "BENCHMQ"
+
+// ApplyEnv overrides config fields with environment variables named after the
+// upper-cased yaml keys of the field path, such as BENCHMQ_SERVER_HOST or
+// BENCHMQ_CLIENT_WILL_TOPIC. lookup is usually os.LookupEnv.
+func (c *Config) ApplyEnv(lookup func(string) (string, bool)) error {
+	return applyEnv(reflect.ValueOf(c).Elem(), EnvPrefix, lookup)
+}
+
+func applyEnv(v reflect.Value, prefix string, lookup func(string) (string, bool)) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, ok := envName(t.Field(i), prefix)
+		if !ok {
+			continue
+		}
+		field := v.Field(i)
+		// Broker profiles are selected by name rather than overridden
+		if field.Kind() == reflect.Map {
+			continue
+		}
+		if field.Kind() == reflect.Struct {
+			if err := applyEnv(field, name, lookup); err != nil {
+				return err
+			}
+			continue
+		}
+		value, set := lookup(name)
+		if !set {
+			continue
+		}
+		if err := setField(field, value); err != nil {
+			return &er.Error{
+				Package: "Config",
+				Func:    "ApplyEnv",
+				Message: er.ErrInvalidEnv,
+				Raw:     fmt.Errorf("%s=%q: %w", name, value, err),
+			}
+		}
+	}
+	return nil
+}
+
+// envName derives the variable name of a field from its yaml key
+func envName(f reflect.StructField, prefix string) (string, bool) {
+	key, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	if key == "" || key == "-" {
+		return "", false
+	}
+	return prefix + "_" + strings.ToUpper(key), true
+}
+
+// setField parses value into the field according to its kind. Pointer fields
+// are allocated, so a variable set to a zero value still counts as set.
+func setField(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.Pointer:
+		elem := reflect.New(field.Type().Elem())
+		if err := setField(elem.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(elem)
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if field.Type() == reflect.TypeOf(time.Duration(0)) {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return err
+			}
+			field.SetInt(int64(d))
+			return nil
+		}
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rayomqio/benchmq/pkg/er"
+)
+
+func TestApplyEnv(t *testing.T) {
+	zero, seven, topic, off := 0, 7, "bench/env", false
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    func(*Config)
+		wantErr bool
+	}{
+		{"unset", nil, func(*Config) {}, false},
+		{"string", map[string]string{"BENCHMQ_SERVER_HOST": "broker"}, func(c *Config) { c.Server.Host = "broker" }, false},
+		{"uint16", map[string]string{"BENCHMQ_SERVER_PORT": "8883"}, func(c *Config) { c.Server.Port = 8883 }, false},
+		{"nested", map[string]string{"BENCHMQ_SERVER_TLS_ENABLED": "true"}, func(c *Config) { c.Server.TLS.Enabled = true }, false},
+		{"byte", map[string]string{"BENCHMQ_CLIENT_WILL_QOS": "2"}, func(c *Config) { c.Client.Will.QoS = 2 }, false},
+		{"client id", map[string]string{"BENCHMQ_CLIENT_CLIENT_ID": "env-id"}, func(c *Config) { c.Client.ClientID = "env-id" }, false},
+		{"pointer", map[string]string{"BENCHMQ_PUB_CLIENTS": "7", "BENCHMQ_PUB_TOPIC": "bench/env"}, func(c *Config) {
+			c.Pub.Clients = &seven
+			c.Pub.Topic = &topic
+		}, false},
+		{"pointer zero", map[string]string{"BENCHMQ_SUB_DELAY": "0", "BENCHMQ_SUB_RETAIN": "false"}, func(c *Config) {
+			c.Sub.Delay = &zero
+			c.Sub.Retain = &off
+		}, false},
+		{"empty string", map[string]string{"BENCHMQ_CLIENT_USERNAME": ""}, func(*Config) {}, false},
+		{"invalid bool", map[string]string{"BENCHMQ_CLIENT_CLEAN_SESSION": "maybe"}, nil, true},
+		{"port overflow", map[string]string{"BENCHMQ_SERVER_PORT": "70000"}, nil, true},
+		{"invalid pointer", map[string]string{"BENCHMQ_CONN_COUNT": "x"}, nil, true},
+		{"brokers ignored", map[string]string{"BENCHMQ_BROKERS": "x"}, func(*Config) {}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookup := func(name string) (string, bool) {
+				v, ok := tt.env[name]
+				return v, ok
+			}
+			var got Config
+			err := got.ApplyEnv(lookup)
+			if tt.wantErr {
+				if !errors.Is(err, er.ErrInvalidEnv) {
+					t.Fatalf("error = %v, want ErrInvalidEnv", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want Config
+			tt.want(&want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("config = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
@@ -6,22 +6,65 @@ import (
 )
 
 var (
-	ErrMqttConnectionFailed = errors.New("mqtt connection failed")
-	ErrEmptyServerHost      = errors.New("server host cannot be empty")
-	ErrInvalidServerPort    = errors.New("server port is invalid")
-	ErrUnmarshalFailed      = errors.New("failed to unmarshal config file")
-	ErrConfigReadFailed     = errors.New("failed to read config file")
-	ErrInvalidQoS           = errors.New("bench: invalid QoS (must be 0, 1, or 2)")
-	ErrInvalidClients       = errors.New("bench: clients must be > 0")
-	ErrInvalidDelay         = errors.New("bench: delay must be >= 0")
-	ErrInvalidPort          = errors.New("bench: port must be in 1..65535")
-	ErrEmptyHost            = errors.New("bench: host must be non-empty")
-	ErrEmptyTopic           = errors.New("bench: topic must be non-empty")
-	ErrNilConfig            = errors.New("bench: config cannot be nil")
-	ErrPublishFailed        = errors.New("mqtt: failed to publish")
-	ErrSubscribeFailed      = errors.New("mqtt: failed to subscribe")
-	ErrUnsubscribeFailed    = errors.New("mqtt: failed to unsubscribe")
-	ErrNilCallback          = errors.New("bench: callback cannot be nil")
+	ErrMqttConnectionFailed      = errors.New("mqtt connection failed")
+	ErrEmptyServerHost           = errors.New("server host cannot be empty")
+	ErrInvalidServerPort         = errors.New("server port is invalid")
+	ErrUnmarshalFailed           = errors.New("failed to unmarshal config file")
+	ErrConfigReadFailed          = errors.New("failed to read config file")
+	ErrInvalidBenchmarkConfig    = errors.New("benchmark config needs clients > 0, delay and count >= 0 and QoS 0, 1, or 2")
+	ErrUnknownBroker             = errors.New("unknown broker profile")
+	ErrInvalidBroker             = errors.New("broker profile needs a host and loadable TLS files")
+	ErrInvalidTLS                = errors.New("failed to load TLS files")
+	ErrConflictingPassword       = errors.New("only one of password, password file, password env and password prompt may be set")
+	ErrPasswordSource            = errors.New("failed to read password")
+	ErrInvalidEnv                = errors.New("invalid environment variable override")
+	ErrDuplicateWill             = errors.New("bench: every client needs a distinct will topic or payload, for example with {{.ClientID}}")
+	ErrInvalidWill               = errors.New("will requires a topic and QoS 0, 1, or 2")
+	ErrInvalidQoS                = errors.New("bench: invalid QoS (must be 0, 1, or 2)")
+	ErrInvalidClients            = errors.New("bench: clients must be > 0")
+	ErrInvalidDelay              = errors.New("bench: delay must be >= 0")
+	ErrInvalidPort               = errors.New("bench: port must be in 1..65535")
+	ErrEmptyHost                 = errors.New("bench: host must be non-empty")
+	ErrEmptyTopic                = errors.New("bench: topic must be non-empty")
+	ErrNilConfig                 = errors.New("bench: config cannot be nil")
+	ErrPublishFailed             = errors.New("mqtt: failed to publish")
+	ErrSubscribeFailed           = errors.New("mqtt: failed to subscribe")
+	ErrUnsubscribeFailed         = errors.New("mqtt: failed to unsubscribe")
+	ErrNilCallback               = errors.New("bench: callback cannot be nil")
+	ErrInvalidConnectConcurrency = errors.New("bench: connect concurrency must be >= 0")
+	ErrInvalidConnectRate        = errors.New("bench: connect rate must be >= 0")
+	ErrInvalidArrival            = errors.New("bench: arrival must be constant, poisson, uniform or burst")
+	ErrInvalidJitter             = errors.New("bench: jitter must be between 0 and the delay")
+	ErrInvalidBurstSize          = errors.New("bench: burst size must be > 0")
+	ErrInvalidHold               = errors.New("bench: hold must be >= 0 and ping window > 0")
+	ErrInvalidMaxInflight        = errors.New("bench: max in-flight must be between 1 and 65535")
+	ErrInvalidPublishTimeout     = errors.New("bench: publish timeout must be > 0")
+	ErrInvalidWarmup             = errors.New("bench: warm-up must be >= 0")
+	ErrInvalidIterations         = errors.New("bench: iterations must be > 0")
+	ErrExportFailed              = errors.New("failed to export report")
+	ErrInvalidPayloadSize        = errors.New("bench: payload size must be a size, a min-max range or a size:weight list of sizes up to 256MB")
+	ErrInvalidPayloadContent     = errors.New("bench: payload content must be random, zeros, text or printable")
+	ErrInvalidTemplate           = errors.New("bench: invalid template")
+	ErrMessageFileRead           = errors.New("bench: failed to read message file")
+	ErrCorpusLoadFailed          = errors.New("bench: failed to load payload corpus")
+	ErrConflictingPayloads       = errors.New("bench: only one of payload file, payload dir, payload size and a message template may be set")
+	ErrInvalidCorpusOrder        = errors.New("bench: payload order must be sequential or random")
+	ErrInvalidProbe              = errors.New("bench: probe topics and count must be >= 0")
+	ErrInvalidProbeTopic         = errors.New("bench: probe topics must not contain wildcards")
+	ErrInvalidShareGroup         = errors.New("bench: share group must be a name without '/', '+' or '#', and share groups need a group name")
+	ErrInvalidPublishers         = errors.New("bench: expected publishers must be >= 0")
+	ErrInvalidSubscriptions      = errors.New("bench: subscriptions per client must be > 0, and more than one needs a topic template")
+	ErrInvalidRetained           = errors.New("bench: retained filter must be set and timeout must be > 0")
+	ErrInvalidWillMonitors       = errors.New("bench: will monitors must be > 0 with a filter, and timeout must be > 0")
+	ErrInvalidSessionTimeout     = errors.New("bench: session timeout must be > 0")
+	ErrInvalidChecksum           = errors.New("bench: checksum must be crc32 or xxhash")
+	ErrInvalidProcessTime        = errors.New("bench: process time must be a duration, a min-max range or exp:<mean>")
+	ErrInvalidProcessing         = errors.New("bench: queue size must be > 0 and slow clients between 0 and clients")
+	ErrScenarioReadFailed        = errors.New("failed to read scenario file")
+	ErrScenarioNoGroups          = errors.New("scenario: at least one group is required")
+	ErrScenarioNoPhases          = errors.New("scenario: at least one phase is required")
+	ErrScenarioInvalidGroup      = errors.New("scenario: invalid client group")
+	ErrScenarioInvalidPhase      = errors.New("scenario: invalid phase")
 )
 
 type Error struct {
@@ -7,6 +7,8 @@ import (
 	"os"
 	"strings"
 	"sync"
+
+	"github.com/rayomqio/benchmq/pkg/redact"
 )
 
 // LogLevel represents logging levels
@@ -51,8 +53,9 @@ func New(config Config) *Logger {
 	var handler slog.Handler
 
 	opts := &slog.HandlerOptions{
-		Level:     convertLevel(config.Level),
-		AddSource: config.AddSource,
+		Level:       convertLevel(config.Level),
+		AddSource:   config.AddSource,
+		ReplaceAttr: redactAttr,
 	}
 
 	if config.Output == nil {
@@ -92,6 +95,23 @@ func New(config Config) *Logger {
 	}
 }
 
+// redactAttr masks secret-named attributes and registered secrets in every
+// string value, the message included
+func redactAttr(_ []string, a slog.Attr) slog.Attr {
+	if redact.IsSecretKey(a.Key) {
+		return slog.String(a.Key, redact.Mask)
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		a.Value = slog.StringValue(redact.String(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			a.Value = slog.StringValue(redact.String(err.Error()))
+		}
+	}
+	return a
+}
+
 // GetGlobalLogger returns the global logger
 func GetGlobalLogger() *Logger {
 	mu.RLock()
@@ -0,0 +1,31 @@
+package logger
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/rayomqio/benchmq/pkg/redact"
+)
+
+func TestRedactAttr(t *testing.T) {
+	redact.Register("pw1")
+
+	tests := []struct {
+		name string
+		attr slog.Attr
+		want string
+	}{
+		{"short password in error", slog.Any("error", errors.New("auth pw1 rejected")), "auth [REDACTED] rejected"},
+		{"string", slog.String("msg", "user:pw1"), "user:[REDACTED]"},
+		{"secret key", slog.String("password", "other"), redact.Mask},
+		{"number", slog.Int("clients", 1), "1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactAttr(nil, tt.attr).Value.String(); got != tt.want {
+				t.Errorf("redactAttr(%v) = %q, want %q", tt.attr, got, tt.want)
+			}
+		})
+	}
+}
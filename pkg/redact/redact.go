@@ -0,0 +1,135 @@
+package redact
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Mask replaces secrets in redacted output
+const Mask = "[REDACTED]"
+
+var (
+	mu      sync.Mutex // Serializes Register
+	secrets atomic.Pointer[[]string]
+)
+
+// Register adds a secret to be masked in every later log line and report.
+// Empty secrets are ignored.
+func Register(secret string) {
+	if secret == "" {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	var list []string
+	if current := secrets.Load(); current != nil {
+		list = *current
+	}
+	for _, s := range list {
+		if s == secret {
+			return
+		}
+	}
+	// Copied, so readers keep using the snapshot they loaded
+	list = append(list[:len(list):len(list)], secret)
+	// Longer secrets first, so one containing another is masked whole
+	for i := len(list) - 1; i > 0 && len(list[i]) > len(list[i-1]); i-- {
+		list[i], list[i-1] = list[i-1], list[i]
+	}
+	secrets.Store(&list)
+}
+
+// String returns s with every registered secret masked
+func String(s string) string {
+	list := secrets.Load()
+	if list == nil {
+		return s
+	}
+	for _, secret := range *list {
+		s = strings.ReplaceAll(s, secret, Mask)
+	}
+	return s
+}
+
+// JSON returns encoded JSON with the values of secret-named keys masked and
+// every registered secret masked inside string values. Keys, numbers and the
+// layout are left untouched.
+func JSON(raw []byte) []byte {
+	out := make([]byte, 0, len(raw))
+	secretKey := false
+	for i := 0; i < len(raw); {
+		c := raw[i]
+		if c != '"' {
+			// An object ending also ends the value of its last key
+			if c == '}' {
+				secretKey = false
+			}
+			out = append(out, c)
+			i++
+			continue
+		}
+
+		end := stringEnd(raw, i)
+		literal := raw[i:end]
+		i = end
+		var s string
+		if err := json.Unmarshal(literal, &s); err != nil {
+			out = append(out, literal...)
+			continue
+		}
+		switch {
+		case isKey(raw, end):
+			secretKey = IsSecretKey(s)
+			out = append(out, literal...)
+		case secretKey:
+			out = appendString(out, Mask)
+		default:
+			if masked := String(s); masked != s {
+				out = appendString(out, masked)
+			} else {
+				out = append(out, literal...)
+			}
+		}
+	}
+	return out
+}
+
+// stringEnd returns the index after the string literal starting at start
+func stringEnd(raw []byte, start int) int {
+	for i := start + 1; i < len(raw); i++ {
+		switch raw[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(raw)
+}
+
+// isKey reports whether the string literal ending at end is an object key
+func isKey(raw []byte, end int) bool {
+	for ; end < len(raw); end++ {
+		switch raw[end] {
+		case ' ', '\t', '\n', '\r':
+		case ':':
+			return true
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+func appendString(out []byte, s string) []byte {
+	quoted, _ := json.Marshal(s)
+	return append(out, quoted...)
+}
+
+// IsSecretKey reports whether an attribute or field named key holds a secret
+func IsSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	return strings.Contains(key, "password") || strings.Contains(key, "secret")
+}